@@ -1,11 +1,13 @@
 package apis
 
 import (
+	"bytes"
 	b64 "encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 )
 
 type Avatax struct {
@@ -14,6 +16,7 @@ type Avatax struct {
 	Password    string
 	AccountID   string
 	LicenseKey  string
+	CompanyCode string
 	Development bool
 	HttpClient  *http.Client
 }
@@ -96,6 +99,11 @@ func (config *Avatax) getAuthorization() (string, error) {
 	return "", fmt.Errorf("No valid authorization configuration provided.")
 }
 
+func (config *Avatax) HasAuthorization() bool {
+	_, err := config.getAuthorization()
+	return err == nil
+}
+
 func (config *Avatax) TaxRatesByAddress(address AvataxAddress) (*AvataxRates, error) {
 	authorization, err := config.getAuthorization()
 	if err != nil {
@@ -147,3 +155,129 @@ func (config *Avatax) TaxRatesByAddress(address AvataxAddress) (*AvataxRates, er
 
 	return &body, nil
 }
+
+type AvataxTransactionLine struct {
+	Number      string  `json:"number"`
+	Quantity    float64 `json:"quantity"`
+	Amount      float64 `json:"amount"`
+	TaxCode     string  `json:"taxCode,omitempty"`
+	ItemCode    string  `json:"itemCode,omitempty"`
+	Description string  `json:"description,omitempty"`
+}
+
+type AvataxTransactionAddresses struct {
+	SingleLocation AvataxTransactionAddress `json:"singleLocation"`
+}
+
+type AvataxTransactionAddress struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	Line3      string `json:"line3,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
+// See https://developer.avalara.com/api-reference/avatax/rest/v2/models/CreateTransactionModel/
+type AvataxCreateTransaction struct {
+	Type         string                     `json:"type"`
+	Code         string                     `json:"code,omitempty"`
+	CompanyCode  string                     `json:"companyCode"`
+	Date         string                     `json:"date"`
+	CustomerCode string                     `json:"customerCode"`
+	Commit       bool                       `json:"commit"`
+	CurrencyCode string                     `json:"currencyCode"`
+	Addresses    AvataxTransactionAddresses `json:"addresses"`
+	Lines        []AvataxTransactionLine    `json:"lines"`
+}
+
+type AvataxTransactionDetail struct {
+	TaxName   string  `json:"taxName"`
+	JurisType string  `json:"jurisType"`
+	Rate      float64 `json:"rate"`
+	Tax       float64 `json:"tax"`
+}
+
+type AvataxTransactionLineResult struct {
+	LineNumber string                    `json:"lineNumber"`
+	Tax        float64                   `json:"tax"`
+	Details    []AvataxTransactionDetail `json:"details"`
+}
+
+type AvataxTransaction struct {
+	ID       int64                         `json:"id"`
+	Code     string                        `json:"code"`
+	Status   string                        `json:"status"`
+	TotalTax float64                       `json:"totalTax"`
+	Lines    []AvataxTransactionLineResult `json:"lines"`
+	Error    *AvataxError                  `json:"error"`
+}
+
+func (config *Avatax) getBaseUrl() string {
+	if config.Development {
+		return "https://sandbox-rest.avatax.com/api/v2"
+	}
+
+	return "https://rest.avatax.com/api/v2"
+}
+
+func (config *Avatax) post(url string, input interface{}) (*AvataxTransaction, error) {
+	authorization, err := config.getAuthorization()
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := config.HttpClient
+
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest("POST", url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Add("Authorization", authorization)
+	request.Header.Add("Content-Type", "application/json")
+
+	res, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	read, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	body := AvataxTransaction{}
+	if err := json.Unmarshal(read, &body); err != nil || body.Error != nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, body.Error
+	}
+
+	return &body, nil
+}
+
+// Calculates the tax for a transaction. SalesOrder transactions are estimates that are not saved,
+// SalesInvoice transactions are saved under their code and reported once they are committed.
+func (config *Avatax) CreateTransaction(transaction AvataxCreateTransaction) (*AvataxTransaction, error) {
+	return config.post(config.getBaseUrl()+"/transactions/create", transaction)
+}
+
+// Commits a saved transaction so it is reported in the company's filings.
+func (config *Avatax) CommitTransaction(companyCode string, code string) (*AvataxTransaction, error) {
+	return config.post(
+		config.getBaseUrl()+"/companies/"+url.PathEscape(companyCode)+"/transactions/"+url.PathEscape(code)+"/commit",
+		map[string]bool{"commit": true})
+}
@@ -13,3 +13,9 @@ type Claims struct {
 type TokenGenerator interface {
 	GenerateToken(ctx context.Context, claims Claims, expirationMinutes int64) (string, error)
 }
+
+// Signs messages, such as the parameters of a link, so they can be trusted when they come back.
+type Signer interface {
+	Sign(message string) string
+	VerifySignature(message string, signature string) bool
+}
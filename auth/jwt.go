@@ -2,6 +2,9 @@ package auth
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
@@ -89,3 +92,15 @@ func (hook *JwtAuthHook) GetClimasFromToken(token string) *Claims {
 
 	return nil
 }
+
+// Signs a message with the JWT secret.
+func (hook *JwtAuthHook) Sign(message string) string {
+	mac := hmac.New(sha256.New, hook.JwtSecret)
+	mac.Write([]byte(message))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (hook *JwtAuthHook) VerifySignature(message string, signature string) bool {
+	return hmac.Equal([]byte(hook.Sign(message)), []byte(signature))
+}
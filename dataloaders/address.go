@@ -0,0 +1,69 @@
+package dataloaders
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graph-gophers/dataloader"
+
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+func LoadAddress(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	database := ctx.Value("database").(*pg.DB)
+
+	ids := make([]int, len(keys))
+	for index, key := range keys {
+		id, ok := key.Raw().(int)
+		if !ok {
+			continue
+		}
+		ids[index] = id
+	}
+
+	dbResults := []*db.Address{}
+	if err := database.
+		Model(&dbResults).
+		AllWithDeleted().
+		WhereIn("address.id IN (?)", ids).
+		Select(); err != nil {
+		results := make([]*dataloader.Result, len(keys))
+		for index, _ := range keys {
+			results[index] = &dataloader.Result{
+				Error: &core.WrappedError{
+					Message:       "Failed to load address.",
+					InternalError: err,
+				},
+			}
+		}
+
+		return results
+	}
+
+	resultMap := map[int]*dataloader.Result{}
+	for _, address := range dbResults {
+		resultMap[address.ID] = &dataloader.Result{
+			Data: address,
+		}
+	}
+
+	results := make([]*dataloader.Result, len(keys))
+	for index, key := range keys {
+		result, ok := resultMap[key.Raw().(int)]
+
+		if !ok {
+			results[index] = &dataloader.Result{
+				Error: &core.WrappedError{
+					Message: "Failed to load address `" + key.String() + "`.",
+				},
+			}
+			continue
+		}
+
+		results[index] = result
+	}
+
+	return results
+}
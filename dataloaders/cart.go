@@ -73,6 +73,7 @@ func LoadSubtotal(ctx context.Context, keys dataloader.Keys) []*dataloader.Resul
 
 var InvalidQuantityError = fmt.Errorf("Quanity for each variant must be greater than 0.")
 var MissmatchedVariantsError = fmt.Errorf("Failed to calculate subtotal. One or more variants is not avaliable for purchase.")
+var OutOfStockError = fmt.Errorf("One or more variants does not have enough stock for the requested quantity.")
 
 func subtotal(database *pg.DB, variants CartKey) (int, error) {
 	if len(variants) == 0 {
@@ -95,6 +96,7 @@ func subtotal(database *pg.DB, variants CartKey) (int, error) {
 		Model(&found).
 		Column("product_variant.id").
 		Column("product_variant.price").
+		Column("product_variant.stock").
 		WhereIn("product_variant.id IN (?)", where).
 		Select(); err != nil {
 		return 0, &core.WrappedError{
@@ -110,6 +112,10 @@ func subtotal(database *pg.DB, variants CartKey) (int, error) {
 	var subtotal int = 0
 	for _, variant := range found {
 		if quantity, ok := quanties[variant.ID]; ok {
+			if variant.Stock < quantity {
+				return 0, OutOfStockError
+			}
+
 			subtotal += variant.Price * int(quantity)
 			continue
 		}
@@ -22,6 +22,8 @@ func (hook hooksDataloaderFunc) PostExecute(ctx context.Context, req core.GraphQ
 	loader.ClearAll()
 	loader = ctx.Value("userTransactions").(*dataloader.Loader)
 	loader.ClearAll()
+	loader = ctx.Value("address").(*dataloader.Loader)
+	loader.ClearAll()
 	loader = ctx.Value("products").(*dataloader.Loader)
 	loader.ClearAll()
 	loader = ctx.Value("adminProducts").(*dataloader.Loader)
@@ -46,6 +48,8 @@ func (hook hooksDataloaderFunc) PostExecute(ctx context.Context, req core.GraphQ
 	loader.ClearAll()
 	loader = ctx.Value("productVariantImages").(*dataloader.Loader)
 	loader.ClearAll()
+	loader = ctx.Value("productVariantStockHistory").(*dataloader.Loader)
+	loader.ClearAll()
 	loader = ctx.Value("transaction").(*dataloader.Loader)
 	loader.ClearAll()
 	loader = ctx.Value("transactions").(*dataloader.Loader)
@@ -54,6 +58,14 @@ func (hook hooksDataloaderFunc) PostExecute(ctx context.Context, req core.GraphQ
 	loader.ClearAll()
 	loader = ctx.Value("transactionLineItems").(*dataloader.Loader)
 	loader.ClearAll()
+	loader = ctx.Value("transactionShipments").(*dataloader.Loader)
+	loader.ClearAll()
+	loader = ctx.Value("transactionParcels").(*dataloader.Loader)
+	loader.ClearAll()
+	loader = ctx.Value("transactionRefunds").(*dataloader.Loader)
+	loader.ClearAll()
+	loader = ctx.Value("transactionStatuses").(*dataloader.Loader)
+	loader.ClearAll()
 	loader = ctx.Value("subtotal").(*dataloader.Loader)
 	loader.ClearAll()
 	loader = ctx.Value("taxes").(*dataloader.Loader)
@@ -66,6 +78,7 @@ var HooksDataloader hooksDataloaderFunc = func(ctx context.Context, req core.Gra
 	ctx = context.WithValue(ctx, "user", dataloader.NewBatchedLoader(LoadUser))
 	ctx = context.WithValue(ctx, "userAddresses", dataloader.NewBatchedLoader(LoadUserAddresses))
 	ctx = context.WithValue(ctx, "userTransactions", dataloader.NewBatchedLoader(LoadUserTransactions))
+	ctx = context.WithValue(ctx, "address", dataloader.NewBatchedLoader(LoadAddress))
 	ctx = context.WithValue(ctx, "adminProducts", dataloader.NewBatchedLoader(LoadAdminProducts))
 	ctx = context.WithValue(ctx, "products", dataloader.NewBatchedLoader(LoadProducts))
 	ctx = context.WithValue(ctx, "product", dataloader.NewBatchedLoader(LoadProduct))
@@ -78,10 +91,15 @@ var HooksDataloader hooksDataloaderFunc = func(ctx context.Context, req core.Gra
 	ctx = context.WithValue(ctx, "productVariant", dataloader.NewBatchedLoader(LoadProductVariant))
 	ctx = context.WithValue(ctx, "productVariantOptions", dataloader.NewBatchedLoader(LoadProductVariantOptions))
 	ctx = context.WithValue(ctx, "productVariantImages", dataloader.NewBatchedLoader(LoadProductVariantImages))
+	ctx = context.WithValue(ctx, "productVariantStockHistory", dataloader.NewBatchedLoader(LoadProductVariantStockHistory))
 	ctx = context.WithValue(ctx, "transaction", dataloader.NewBatchedLoader(LoadTransaction))
 	ctx = context.WithValue(ctx, "transactions", dataloader.NewBatchedLoader(LoadTransactions))
 	ctx = context.WithValue(ctx, "transactionAddresses", dataloader.NewBatchedLoader(LoadTransactionAddresses))
 	ctx = context.WithValue(ctx, "transactionLineItems", dataloader.NewBatchedLoader(LoadTransactionLineItems))
+	ctx = context.WithValue(ctx, "transactionShipments", dataloader.NewBatchedLoader(LoadTransactionShipments))
+	ctx = context.WithValue(ctx, "transactionParcels", dataloader.NewBatchedLoader(LoadTransactionParcels))
+	ctx = context.WithValue(ctx, "transactionRefunds", dataloader.NewBatchedLoader(LoadTransactionRefunds))
+	ctx = context.WithValue(ctx, "transactionStatuses", dataloader.NewBatchedLoader(LoadTransactionStatuses))
 	ctx = context.WithValue(ctx, "subtotal", dataloader.NewBatchedLoader(LoadSubtotal))
 	ctx = context.WithValue(ctx, "taxes", dataloader.NewBatchedLoader(LoadTaxes))
 	ctx = context.WithValue(ctx, "shippingEstimations", dataloader.NewBatchedLoader(LoadShippingEstimations))
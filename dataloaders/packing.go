@@ -0,0 +1,121 @@
+package dataloaders
+
+import (
+	"context"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graph-gophers/dataloader"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+	"github.com/jacob-ebey/golang-ecomm/utilities"
+)
+
+// Packs the units of the cart into the store's shipping boxes.
+func PackCart(ctx context.Context, cart CartKey) ([]*utilities.PackedParcel, error) {
+	database := ctx.Value("database").(*pg.DB)
+	productVariant := ctx.Value("productVariant").(*dataloader.Loader)
+
+	ids := make(dataloader.Keys, len(cart))
+	for index, item := range cart {
+		ids[index] = IntKey(item.VariantID)
+	}
+
+	variants, errs := productVariant.LoadMany(ctx, ids)()
+	if errs != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not get variants to pack.",
+			InternalError: HandleErrors(errs),
+		}
+	}
+
+	shippingBoxes := []*db.ShippingBox{}
+	if err := database.
+		Model(&shippingBoxes).
+		OrderExpr("shipping_box.id ASC").
+		Select(); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not get shipping boxes.",
+			InternalError: err,
+		}
+	}
+
+	boxes := make([]utilities.PackingBox, len(shippingBoxes))
+	for index, box := range shippingBoxes {
+		boxes[index] = utilities.PackingBox{
+			ID:        box.ID,
+			Length:    box.Length,
+			Width:     box.Width,
+			Height:    box.Height,
+			Weight:    box.Weight,
+			MaxWeight: box.MaxWeight,
+		}
+	}
+
+	items := []utilities.PackingItem{}
+	for index, tempVariant := range variants {
+		variant := tempVariant.(*db.ProductVariant)
+
+		for i := 0; i < cart[index].Quantity; i++ {
+			items = append(items, utilities.PackingItem{
+				ID:     variant.ID,
+				Length: variant.Length,
+				Width:  variant.Width,
+				Height: variant.Height,
+				Weight: variant.Weight,
+			})
+		}
+	}
+
+	return utilities.Pack(items, boxes), nil
+}
+
+func NewShippingParcels(packed []*utilities.PackedParcel) []services.ShippingParcel {
+	parcels := make([]services.ShippingParcel, len(packed))
+	for index, parcel := range packed {
+		parcels[index] = services.ShippingParcel{
+			Length: parcel.Length,
+			Width:  parcel.Width,
+			Height: parcel.Height,
+			Weight: parcel.Weight,
+		}
+	}
+
+	return parcels
+}
+
+// Creates the parcels of a shipment's packing plan. The shipment ID is set when the parcels are inserted.
+func NewTransactionParcels(packed []*utilities.PackedParcel) []*db.TransactionParcel {
+	parcels := make([]*db.TransactionParcel, len(packed))
+	for index, parcel := range packed {
+		contents := []*db.ParcelContent{}
+		contentMap := map[int]*db.ParcelContent{}
+		for _, item := range parcel.Items {
+			content, ok := contentMap[item.ID]
+			if !ok {
+				content = &db.ParcelContent{
+					ProductVariantID: item.ID,
+				}
+				contentMap[item.ID] = content
+				contents = append(contents, content)
+			}
+
+			content.Quantity++
+		}
+
+		parcels[index] = &db.TransactionParcel{
+			Length:   parcel.Length,
+			Width:    parcel.Width,
+			Height:   parcel.Height,
+			Weight:   parcel.Weight,
+			Contents: contents,
+		}
+
+		if parcel.Box != nil {
+			parcels[index].ShippingBoxID = parcel.Box.ID
+		}
+	}
+
+	return parcels
+}
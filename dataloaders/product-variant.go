@@ -300,3 +300,55 @@ func LoadProductVariantImages(ctx context.Context, keys dataloader.Keys) []*data
 
 	return results
 }
+
+func LoadProductVariantStockHistory(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	database := ctx.Value("database").(*pg.DB)
+
+	ids := make([]int, len(keys))
+	for index, key := range keys {
+		id, ok := key.Raw().(int)
+		if !ok {
+			continue
+		}
+		ids[index] = id
+	}
+
+	dbResults := []*db.InventoryAdjustment{}
+	if err := database.
+		Model(&dbResults).
+		OrderExpr("inventory_adjustment.id DESC").
+		WhereIn("inventory_adjustment.product_variant_id IN (?)", ids).
+		Select(); err != nil {
+		results := make([]*dataloader.Result, len(keys))
+		for index, _ := range keys {
+			results[index] = &dataloader.Result{
+				Error: &core.WrappedError{
+					Message:       "Failed to load product variant stock history.",
+					InternalError: err,
+				},
+			}
+		}
+
+		return results
+	}
+
+	resultMap := map[int][]*db.InventoryAdjustment{}
+	for _, adjustment := range dbResults {
+		if resultMap[adjustment.ProductVariantID] == nil {
+			resultMap[adjustment.ProductVariantID] = []*db.InventoryAdjustment{}
+		}
+
+		resultMap[adjustment.ProductVariantID] = append(resultMap[adjustment.ProductVariantID], adjustment)
+	}
+
+	results := make([]*dataloader.Result, len(keys))
+	for index, key := range keys {
+		result, _ := resultMap[key.Raw().(int)]
+
+		results[index] = &dataloader.Result{
+			Data: result,
+		}
+	}
+
+	return results
+}
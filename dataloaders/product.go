@@ -32,7 +32,7 @@ func LoadProducts(ctx context.Context, keys dataloader.Keys) []*dataloader.Resul
 
 		if err := database.
 			Model(&results).
-			OrderExpr("id DESC").
+			OrderExpr(page.OrderExpr()).
 			Offset(page.Skip).
 			Limit(page.Limit).
 			Where("product.published IS TRUE").
@@ -80,7 +80,7 @@ func LoadAdminProducts(ctx context.Context, keys dataloader.Keys) []*dataloader.
 
 		if err := database.
 			Model(&results).
-			OrderExpr("id DESC").
+			OrderExpr(page.OrderExpr()).
 			Offset(page.Skip).
 			Limit(page.Limit).
 			Select(); err != nil {
@@ -25,13 +25,37 @@ func (key IntKey) Raw() interface{} {
 	return int(key)
 }
 
+const (
+	PaginationSortNewest          = "NEWEST"
+	PaginationSortOldest          = "OLDEST"
+	PaginationSortRecentlyUpdated = "RECENTLY_UPDATED"
+	PaginationSortLeastUpdated    = "LEAST_RECENTLY_UPDATED"
+)
+
+var paginationSortExpressions = map[string]string{
+	PaginationSortNewest:          "created_at DESC NULLS LAST, id DESC",
+	PaginationSortOldest:          "created_at ASC NULLS FIRST, id ASC",
+	PaginationSortRecentlyUpdated: "updated_at DESC NULLS LAST, id DESC",
+	PaginationSortLeastUpdated:    "updated_at ASC NULLS FIRST, id ASC",
+}
+
 type PaginationKey struct {
 	Skip  int
 	Limit int
+	Sort  string
 }
 
 func (key PaginationKey) String() string {
-	return fmt.Sprintf("%d|%d", key.Skip, key.Limit)
+	return fmt.Sprintf("%d|%d|%s", key.Skip, key.Limit, key.Sort)
+}
+
+// The order of the page. Pages are sorted by ID, newest first, when no sort is given.
+func (key PaginationKey) OrderExpr() string {
+	if expression, ok := paginationSortExpressions[key.Sort]; ok {
+		return expression
+	}
+
+	return "id DESC"
 }
 
 func (key PaginationKey) Raw() interface{} {
@@ -3,29 +3,19 @@ package dataloaders
 import (
 	"context"
 	"fmt"
-	"math"
-	"strconv"
 
-	"github.com/jacob-ebey/go-shippo/client"
-	"github.com/jacob-ebey/go-shippo/models"
+	"github.com/go-pg/pg/v9"
 	"github.com/graph-gophers/dataloader"
 	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
 	core "github.com/jacob-ebey/graphql-core"
 )
 
-type ShippingEstimation struct {
-	ID            string
-	Price         int
-	Service       string
-	Carrier       string
-	DurationTerms string
-}
-
-type ShippingParcel struct {
-	Length float64
-	Width  float64
-	Height float64
-	Weight float64
+// The variants of a cart that ship from the same origin, and the rates to ship them.
+type ShippingEstimationGroup struct {
+	Origin      *db.Address
+	Variants    CartKey
+	Estimations []*services.ShippingEstimation
 }
 
 type ShippingEstimationKey struct {
@@ -41,6 +31,8 @@ func (key ShippingEstimationKey) Raw() interface{} {
 	return key
 }
 
+var NoOriginError = fmt.Errorf("No origin address has been configured to ship from.")
+
 func LoadShippingEstimations(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
 	results := make([]*dataloader.Result, len(keys))
 
@@ -54,14 +46,21 @@ func LoadShippingEstimations(ctx context.Context, keys dataloader.Keys) []*datal
 			continue
 		}
 
-		estimations, err := loadShippingEstimations(ctx, toEstimate.Address, db.Address{
-			Name:       "Space Needle",
-			Line1:      "400 Broad St",
-			City:       "Seattle",
-			Region:     "WA",
-			Country:    "USA",
-			PostalCode: "98109",
-		}, toEstimate.Variants)
+		groups, err := GroupCartByOrigin(ctx, toEstimate.Variants)
+		if err != nil {
+			results[index] = &dataloader.Result{
+				Error: err,
+			}
+
+			continue
+		}
+
+		for _, group := range groups {
+			group.Estimations, err = loadShippingEstimations(ctx, toEstimate.Address, *group.Origin, group.Variants)
+			if err != nil {
+				break
+			}
+		}
 
 		if err != nil {
 			results[index] = &dataloader.Result{
@@ -72,48 +71,22 @@ func LoadShippingEstimations(ctx context.Context, keys dataloader.Keys) []*datal
 		}
 
 		results[index] = &dataloader.Result{
-			Data: estimations,
+			Data: groups,
 		}
 	}
 
 	return results
 }
 
-func createAddress(shippoClient *client.Client, address db.Address) (*models.Address, error) {
-	return shippoClient.CreateAddress(&models.AddressInput{
-		Name:     address.Name,
-		Street1:  address.Line1,
-		Street2:  address.Line2,
-		Street3:  address.Line3,
-		City:     address.City,
-		State:    address.Region,
-		Zip:      address.PostalCode,
-		Country:  address.Country,
-		Validate: true,
-	})
-}
-
-func loadShippingEstimations(
-	ctx context.Context,
-	toAddr db.Address,
-	fromAddr db.Address,
-	toEstimate []CartVariant) ([]*ShippingEstimation, error) {
-	shippoClient := ctx.Value("shippo").(*client.Client)
+// Splits the cart into groups by the origin address each variant ships from. Variants without an
+// origin ship from the first origin address that was created. Groups are ordered by origin ID.
+func GroupCartByOrigin(ctx context.Context, cart CartKey) ([]*ShippingEstimationGroup, error) {
+	database := ctx.Value("database").(*pg.DB)
 	productVariant := ctx.Value("productVariant").(*dataloader.Loader)
 
-	addressFrom, err := createAddress(shippoClient, fromAddr)
-	if err != nil {
-		return nil, err // TODO: Lookover the type of messages this error has
-	}
-
-	addressTo, err := createAddress(shippoClient, toAddr)
-	if err != nil {
-		return nil, err
-	}
-
-	ids := make([]dataloader.Key, len(toEstimate))
-	for index, toEst := range toEstimate {
-		ids[index] = IntKey(toEst.VariantID)
+	ids := make(dataloader.Keys, len(cart))
+	for index, item := range cart {
+		ids[index] = IntKey(item.VariantID)
 	}
 
 	variants, errs := productVariant.LoadMany(ctx, ids)()
@@ -124,68 +97,93 @@ func loadShippingEstimations(
 		}
 	}
 
-	parcels := map[int]*models.Parcel{}
+	originIDs := map[int]int{}
+	needsDefault := false
 	for _, tempVariant := range variants {
 		variant := tempVariant.(*db.ProductVariant)
 
-		parcelInput := &models.ParcelInput{
-			Length:       fmt.Sprintf("%.2f", variant.Length),
-			Width:        fmt.Sprintf("%.2f", variant.Width),
-			Height:       fmt.Sprintf("%.2f", variant.Height),
-			DistanceUnit: models.DistanceUnitInch,
-			Weight:       fmt.Sprintf("%.2f", variant.Weight),
-			MassUnit:     models.MassUnitOunce,
+		originIDs[variant.ID] = variant.ShipsFromID
+		if variant.ShipsFromID == 0 {
+			needsDefault = true
 		}
-		parcel, err := shippoClient.CreateParcel(parcelInput)
-		if err != nil {
+	}
+
+	if needsDefault {
+		defaultOrigin := db.Address{}
+		if err := database.
+			Model(&defaultOrigin).
+			Column("address.id").
+			Where("address.origin IS TRUE").
+			OrderExpr("address.id ASC").
+			Limit(1).
+			Select(); err != nil {
+			if err == pg.ErrNoRows {
+				return nil, NoOriginError
+			}
+
 			return nil, &core.WrappedError{
-				Message:       "Could not create parcel.",
+				Message:       "Could not get default origin address.",
 				InternalError: err,
 			}
 		}
 
-		parcels[variant.ID] = parcel
+		for variantID, originID := range originIDs {
+			if originID == 0 {
+				originIDs[variantID] = defaultOrigin.ID
+			}
+		}
 	}
 
-	parcelsToEstimate := []string{}
-	for _, item := range toEstimate {
-		for i := 0; i < item.Quantity; i++ {
-			parcelsToEstimate = append(parcelsToEstimate, parcels[item.VariantID].ObjectID)
-		}
+	where := []int{}
+	for _, originID := range originIDs {
+		where = append(where, originID)
 	}
 
-	shipment, err := shippoClient.CreateShipment(&models.ShipmentInput{
-		AddressFrom: addressFrom.ObjectID,
-		AddressTo:   addressTo.ObjectID,
-		Parcels:     parcelsToEstimate,
-		Async:       false,
-	})
-	if err != nil {
+	origins := []*db.Address{}
+	if err := database.
+		Model(&origins).
+		WhereIn("address.id IN (?)", where).
+		OrderExpr("address.id ASC").
+		Select(); err != nil {
 		return nil, &core.WrappedError{
-			Message:       "Could not create shipping estimation.",
+			Message:       "Could not get origin addresses.",
 			InternalError: err,
 		}
 	}
 
-	estimations := make([]*ShippingEstimation, len(shipment.Rates))
-	for index, rate := range shipment.Rates {
-		amount, err := strconv.ParseFloat(rate.Amount, 64)
-		if err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not convert estimation price.",
-				InternalError: err,
-			}
+	groups := make([]*ShippingEstimationGroup, len(origins))
+	groupMap := map[int]*ShippingEstimationGroup{}
+	for index, origin := range origins {
+		groups[index] = &ShippingEstimationGroup{
+			Origin:   origin,
+			Variants: CartKey{},
 		}
-		price := int(math.Round(amount * 100))
-
-		estimations[index] = &ShippingEstimation{
-			ID:            rate.ObjectID,
-			Price:         price,
-			Carrier:       rate.Provider,
-			Service:       rate.ServiceLevel.Name,
-			DurationTerms: rate.DurationTerms,
+		groupMap[origin.ID] = groups[index]
+	}
+
+	for _, item := range cart {
+		group, ok := groupMap[originIDs[item.VariantID]]
+		if !ok {
+			return nil, NoOriginError
 		}
+
+		group.Variants = append(group.Variants, item)
+	}
+
+	return groups, nil
+}
+
+func loadShippingEstimations(
+	ctx context.Context,
+	toAddr db.Address,
+	fromAddr db.Address,
+	toEstimate []CartVariant) ([]*services.ShippingEstimation, error) {
+	shippingProvider := ctx.Value("shippingProvider").(services.ShippingProvider)
+
+	packed, err := PackCart(ctx, toEstimate)
+	if err != nil {
+		return nil, err
 	}
 
-	return estimations, nil
+	return shippingProvider.RateParcels(ctx, fromAddr, toAddr, NewShippingParcels(packed))
 }
@@ -3,13 +3,11 @@ package dataloaders
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/graph-gophers/dataloader"
-	"github.com/jacob-ebey/golang-ecomm/apis"
-	core "github.com/jacob-ebey/graphql-core"
 
 	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
 )
 
 type Taxes struct {
@@ -24,7 +22,7 @@ type Rate struct {
 }
 
 func LoadTaxes(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
-	avatax := ctx.Value("avatax").(*apis.Avatax)
+	taxProvider := ctx.Value("taxProvider").(services.TaxProvider)
 
 	results := make([]*dataloader.Result, len(keys))
 
@@ -38,16 +36,7 @@ func LoadTaxes(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
 			continue
 		}
 
-		taxes, err := loadTaxes(avatax, apis.AvataxAddress{
-			Line1:      address.Line1,
-			Line2:      address.Line2,
-			Line3:      address.Line3,
-			City:       address.City,
-			Region:     address.Region,
-			Country:    address.Country,
-			PostalCode: address.PostalCode,
-		})
-
+		rates, err := taxProvider.TaxRatesByAddress(ctx, address)
 		if err != nil {
 			results[index] = &dataloader.Result{
 				Error: err,
@@ -56,46 +45,22 @@ func LoadTaxes(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
 			continue
 		}
 
-		results[index] = &dataloader.Result{
-			Data: taxes,
-		}
-	}
-
-	return results
-}
-
-func loadTaxes(avatax *apis.Avatax, address apis.AvataxAddress) (*Taxes, error) {
-	rates, err := avatax.TaxRatesByAddress(address)
-	if err != nil {
-		switch err.(type) {
-		case *apis.AvataxError:
-			if strings.Contains(err.Error(), "CreateTransaction()") {
-				return nil, &core.WrappedError{
-					Message:       "Could not get taxes for address.",
-					InternalError: err,
-				}
-			}
-
-			return nil, err
-		default:
-			return nil, &core.WrappedError{
-				Message:       "Could not get taxes for address.",
-				InternalError: err,
+		subRates := make([]Rate, len(rates.Rates))
+		for index, rate := range rates.Rates {
+			subRates[index] = Rate{
+				Rate: rate.Rate,
+				Name: rate.Name,
+				Type: rate.Type,
 			}
 		}
-	}
 
-	subRates := make([]Rate, len(rates.Rates))
-	for index, rate := range rates.Rates {
-		subRates[index] = Rate{
-			Rate: rate.Rate,
-			Name: rate.Name,
-			Type: rate.Type,
+		results[index] = &dataloader.Result{
+			Data: &Taxes{
+				TotalRate: rates.TotalRate,
+				Rates:     subRates,
+			},
 		}
 	}
 
-	return &Taxes{
-		TotalRate: rates.TotalRate,
-		Rates:     subRates,
-	}, nil
+	return results
 }
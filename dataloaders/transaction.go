@@ -32,7 +32,7 @@ func LoadTransactions(ctx context.Context, keys dataloader.Keys) []*dataloader.R
 
 		if err := database.
 			Model(&results).
-			OrderExpr("id DESC").
+			OrderExpr(page.OrderExpr()).
 			Offset(page.Skip).
 			Limit(page.Limit).
 			Select(); err != nil {
@@ -231,3 +231,197 @@ func LoadTransactionAddresses(ctx context.Context, keys dataloader.Keys) []*data
 
 	return results
 }
+
+func LoadTransactionShipments(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	database := ctx.Value("database").(*pg.DB)
+
+	ids := make([]int, len(keys))
+	for index, key := range keys {
+		id, ok := key.Raw().(int)
+		if !ok {
+			continue
+		}
+		ids[index] = id
+	}
+
+	dbResults := []*db.TransactionShipment{}
+	if err := database.
+		Model(&dbResults).
+		OrderExpr("transaction_shipment.id ASC").
+		WhereIn("transaction_shipment.transaction_id IN (?)", ids).
+		Select(); err != nil {
+		results := make([]*dataloader.Result, len(keys))
+		for index, _ := range keys {
+			results[index] = &dataloader.Result{
+				Error: &core.WrappedError{
+					Message:       "Failed to load transaction shipments.",
+					InternalError: err,
+				},
+			}
+		}
+
+		return results
+	}
+
+	resultMap := map[int][]*db.TransactionShipment{}
+	for _, shipment := range dbResults {
+		if resultMap[shipment.TransactionID] == nil {
+			resultMap[shipment.TransactionID] = []*db.TransactionShipment{}
+		}
+
+		resultMap[shipment.TransactionID] = append(resultMap[shipment.TransactionID], shipment)
+	}
+
+	results := make([]*dataloader.Result, len(keys))
+	for index, key := range keys {
+		result, _ := resultMap[key.Raw().(int)]
+
+		results[index] = &dataloader.Result{
+			Data: result,
+		}
+	}
+
+	return results
+}
+
+func LoadTransactionParcels(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	database := ctx.Value("database").(*pg.DB)
+
+	ids := make([]int, len(keys))
+	for index, key := range keys {
+		id, ok := key.Raw().(int)
+		if !ok {
+			continue
+		}
+		ids[index] = id
+	}
+
+	dbResults := []*db.TransactionParcel{}
+	if err := database.
+		Model(&dbResults).
+		OrderExpr("transaction_parcel.id ASC").
+		WhereIn("transaction_parcel.transaction_shipment_id IN (?)", ids).
+		Select(); err != nil {
+		results := make([]*dataloader.Result, len(keys))
+		for index, _ := range keys {
+			results[index] = &dataloader.Result{
+				Error: &core.WrappedError{
+					Message:       "Failed to load transaction parcels.",
+					InternalError: err,
+				},
+			}
+		}
+
+		return results
+	}
+
+	resultMap := map[int][]*db.TransactionParcel{}
+	for _, parcel := range dbResults {
+		resultMap[parcel.TransactionShipmentID] = append(resultMap[parcel.TransactionShipmentID], parcel)
+	}
+
+	results := make([]*dataloader.Result, len(keys))
+	for index, key := range keys {
+		results[index] = &dataloader.Result{
+			Data: resultMap[key.Raw().(int)],
+		}
+	}
+
+	return results
+}
+
+func LoadTransactionRefunds(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	database := ctx.Value("database").(*pg.DB)
+
+	ids := make([]int, len(keys))
+	for index, key := range keys {
+		id, ok := key.Raw().(int)
+		if !ok {
+			continue
+		}
+		ids[index] = id
+	}
+
+	dbResults := []*db.TransactionRefund{}
+	if err := database.
+		Model(&dbResults).
+		OrderExpr("transaction_refund.id ASC").
+		WhereIn("transaction_refund.transaction_id IN (?)", ids).
+		Select(); err != nil {
+		results := make([]*dataloader.Result, len(keys))
+		for index, _ := range keys {
+			results[index] = &dataloader.Result{
+				Error: &core.WrappedError{
+					Message:       "Failed to load transaction refunds.",
+					InternalError: err,
+				},
+			}
+		}
+
+		return results
+	}
+
+	resultMap := map[int][]*db.TransactionRefund{}
+	for _, refund := range dbResults {
+		resultMap[refund.TransactionID] = append(resultMap[refund.TransactionID], refund)
+	}
+
+	results := make([]*dataloader.Result, len(keys))
+	for index, key := range keys {
+		result, _ := resultMap[key.Raw().(int)]
+
+		results[index] = &dataloader.Result{
+			Data: result,
+		}
+	}
+
+	return results
+}
+
+func LoadTransactionStatuses(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	database := ctx.Value("database").(*pg.DB)
+
+	ids := make([]int, len(keys))
+	for index, key := range keys {
+		id, ok := key.Raw().(int)
+		if !ok {
+			continue
+		}
+		ids[index] = id
+	}
+
+	dbResults := []*db.TransactionStatus{}
+	if err := database.
+		Model(&dbResults).
+		OrderExpr("transaction_status.id ASC").
+		WhereIn("transaction_status.transaction_id IN (?)", ids).
+		Select(); err != nil {
+		results := make([]*dataloader.Result, len(keys))
+		for index, _ := range keys {
+			results[index] = &dataloader.Result{
+				Error: &core.WrappedError{
+					Message:       "Failed to load transaction statuses.",
+					InternalError: err,
+				},
+			}
+		}
+
+		return results
+	}
+
+	resultMap := map[int][]*db.TransactionStatus{}
+	for _, status := range dbResults {
+		resultMap[status.TransactionID] = append(resultMap[status.TransactionID], status)
+	}
+
+	results := make([]*dataloader.Result, len(keys))
+	for index, key := range keys {
+		result, _ := resultMap[key.Raw().(int)]
+
+		results[index] = &dataloader.Result{
+			Data: result,
+		}
+	}
+
+	return results
+}
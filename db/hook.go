@@ -2,10 +2,9 @@ package db
 
 import (
 	"context"
-	"reflect"
+	"fmt"
 
 	"github.com/go-pg/pg/v9"
-	"github.com/go-pg/pg/v9/orm"
 
 	core "github.com/jacob-ebey/graphql-core"
 )
@@ -14,39 +13,23 @@ type DatabaseHook struct {
 	Database *pg.DB
 }
 
-func NewDatabaseHook(options *pg.Options) (*DatabaseHook, error) {
+// Connects to the database. When migrate is true, the migrations that have not been applied yet are
+// applied first.
+func NewDatabaseHook(options *pg.Options, migrate bool) (*DatabaseHook, error) {
 	database := pg.Connect(options)
 
-	types := []interface{}{
-		(*User)(nil),
-		(*Image)(nil),
-		(*Address)(nil),
-		(*Product)(nil),
-		(*ProductImage)(nil),
-		(*ProductOption)(nil),
-		(*ProductOptionValue)(nil),
-		(*ProductVariant)(nil),
-		(*ProductVariantOption)(nil),
-		(*ProductVariantImage)(nil),
-		(*Transaction)(nil),
-		(*TransactionAddressInfo)(nil),
-		(*TransactionLineItem)(nil),
-		(*TransactionStatus)(nil),
-	}
-
-	for _, model := range types {
-		err := database.CreateTable(model, &orm.CreateTableOptions{
-			// Temp:          dev,
-			IfNotExists:   true,
-			FKConstraints: true,
-		})
-
+	if migrate {
+		applied, err := Migrate(database, Migrations)
 		if err != nil {
 			return nil, &core.WrappedError{
-				Message:       "Failed to create table for type \"" + reflect.TypeOf(model).String() + "\".",
+				Message:       "Failed to migrate the database.",
 				InternalError: err,
 			}
 		}
+
+		for _, migration := range applied {
+			fmt.Printf("Applied migration %d: %s\n", migration.Version, migration.Description)
+		}
 	}
 
 	return &DatabaseHook{
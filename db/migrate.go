@@ -0,0 +1,195 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+)
+
+// An arbitrary key for the advisory lock held while migrating, so instances that boot at the same
+// time apply the migrations one after the other.
+const migrationLockID = 7164302945
+
+var NoMigrationsToRollBackError = fmt.Errorf("There are no applied migrations to roll back.")
+
+// A versioned change to the schema. Migrations are applied in the order of their versions, and once
+// applied a migration must not be edited; add a new one instead.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *pg.Tx) error
+	Down        func(tx *pg.Tx) error
+}
+
+// The record of an applied migration in the schema_migrations table.
+type SchemaMigration struct {
+	Version     int       `pg:",pk"`
+	Description string    `pg:",notnull"`
+	AppliedAt   time.Time `pg:",notnull"`
+}
+
+// A migration along with when it was applied, if it has been.
+type MigrationStatus struct {
+	Migration *Migration
+	AppliedAt *time.Time
+}
+
+// Creates a migration that executes SQL statements in order.
+func SQLMigration(version int, description string, up []string, down []string) *Migration {
+	exec := func(statements []string) func(tx *pg.Tx) error {
+		return func(tx *pg.Tx) error {
+			for _, statement := range statements {
+				if _, err := tx.Exec(statement); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
+
+	return &Migration{
+		Version:     version,
+		Description: description,
+		Up:          exec(up),
+		Down:        exec(down),
+	}
+}
+
+func sortedMigrations(migrations []*Migration) []*Migration {
+	sorted := make([]*Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return sorted
+}
+
+// Runs fn in a transaction that holds the migration lock, once the schema_migrations table exists.
+// The lock is released when the transaction ends.
+func withMigrationLock(database *pg.DB, fn func(tx *pg.Tx, applied map[int]*SchemaMigration) error) error {
+	return database.RunInTransaction(func(tx *pg.Tx) error {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version bigint PRIMARY KEY,
+			description text NOT NULL,
+			applied_at timestamptz NOT NULL
+		)`); err != nil {
+			return err
+		}
+
+		records := []*SchemaMigration{}
+		if err := tx.Model(&records).Select(); err != nil {
+			return err
+		}
+
+		applied := map[int]*SchemaMigration{}
+		for _, record := range records {
+			applied[record.Version] = record
+		}
+
+		return fn(tx, applied)
+	})
+}
+
+// Applies the migrations that have not been applied yet, in order, and returns them. Either all of
+// them are applied or, if one fails, none are.
+func Migrate(database *pg.DB, migrations []*Migration) ([]*Migration, error) {
+	result := []*Migration{}
+
+	err := withMigrationLock(database, func(tx *pg.Tx, applied map[int]*SchemaMigration) error {
+		for _, migration := range sortedMigrations(migrations) {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := migration.Up(tx); err != nil {
+				return fmt.Errorf("Migration %d (%s) failed: %v", migration.Version, migration.Description, err)
+			}
+
+			if _, err := tx.Model(&SchemaMigration{
+				Version:     migration.Version,
+				Description: migration.Description,
+				AppliedAt:   time.Now(),
+			}).Insert(); err != nil {
+				return err
+			}
+
+			result = append(result, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Rolls back the most recently applied migrations, up to steps of them, and returns them in the order
+// they were rolled back.
+func Rollback(database *pg.DB, migrations []*Migration, steps int) ([]*Migration, error) {
+	result := []*Migration{}
+
+	err := withMigrationLock(database, func(tx *pg.Tx, applied map[int]*SchemaMigration) error {
+		sorted := sortedMigrations(migrations)
+		for index := len(sorted) - 1; index >= 0 && len(result) < steps; index-- {
+			migration := sorted[index]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			if err := migration.Down(tx); err != nil {
+				return fmt.Errorf("Rolling back migration %d (%s) failed: %v", migration.Version, migration.Description, err)
+			}
+
+			if _, err := tx.Model(&SchemaMigration{Version: migration.Version}).WherePK().Delete(); err != nil {
+				return err
+			}
+
+			result = append(result, migration)
+		}
+
+		if len(result) == 0 {
+			return NoMigrationsToRollBackError
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Lists every migration in order along with when it was applied.
+func GetMigrationStatus(database *pg.DB, migrations []*Migration) ([]*MigrationStatus, error) {
+	result := []*MigrationStatus{}
+
+	err := withMigrationLock(database, func(tx *pg.Tx, applied map[int]*SchemaMigration) error {
+		for _, migration := range sortedMigrations(migrations) {
+			status := &MigrationStatus{Migration: migration}
+			if record, ok := applied[migration.Version]; ok {
+				appliedAt := record.AppliedAt
+				status.AppliedAt = &appliedAt
+			}
+
+			result = append(result, status)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
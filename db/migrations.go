@@ -0,0 +1,224 @@
+package db
+
+// The migrations of the schema, in order. Add new migrations to the end with the next version.
+var Migrations = []*Migration{
+	SQLMigration(1, "Create the initial tables",
+		[]string{
+			`CREATE TABLE IF NOT EXISTS "users" ("id" bigserial, "email" text NOT NULL UNIQUE, "password" text NOT NULL, "role" text, PRIMARY KEY ("id"), UNIQUE ("email"))`,
+			`CREATE TABLE IF NOT EXISTS "images" ("deleted_at" timestamptz, "id" bigserial, "name" text, "raw" text, "thumbnail" text, "height600" text, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "addresses" ("deleted_at" timestamptz, "id" bigserial, "name" text NOT NULL, "line1" text NOT NULL, "line2" text, "line3" text, "city" text NOT NULL, "region" text NOT NULL, "postal_code" text NOT NULL, "country" text NOT NULL, "user_id" bigint, PRIMARY KEY ("id"), FOREIGN KEY ("user_id") REFERENCES "users" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "products" ("deleted_at" timestamptz, "id" bigserial, "slug" text NOT NULL UNIQUE, "name" text NOT NULL, "description" text NOT NULL, "details" text, "published" boolean, PRIMARY KEY ("id"), UNIQUE ("slug"))`,
+			`CREATE TABLE IF NOT EXISTS "product_images" ("product_id" bigint, "image_id" bigint, FOREIGN KEY ("product_id") REFERENCES "products" ("id"), FOREIGN KEY ("image_id") REFERENCES "images" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "product_options" ("deleted_at" timestamptz, "id" bigserial, "label" text NOT NULL, "product_id" bigint NOT NULL, PRIMARY KEY ("id"), FOREIGN KEY ("product_id") REFERENCES "products" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "product_option_values" ("deleted_at" timestamptz, "id" bigserial, "value" text NOT NULL, "product_option_id" bigint NOT NULL, PRIMARY KEY ("id"), FOREIGN KEY ("product_option_id") REFERENCES "product_options" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "product_variants" ("deleted_at" timestamptz, "id" bigserial, "name" text, "price" bigint NOT NULL, "length" double precision NOT NULL, "width" double precision NOT NULL, "height" double precision NOT NULL, "weight" double precision NOT NULL, "product_id" bigint NOT NULL, "ships_from_id" bigint, PRIMARY KEY ("id"), FOREIGN KEY ("product_id") REFERENCES "products" ("id"), FOREIGN KEY ("ships_from_id") REFERENCES "addresses" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "product_variant_options" ("deleted_at" timestamptz, "product_option_value_id" bigint NOT NULL, "product_variant_id" bigint NOT NULL, "product_id" bigint NOT NULL, FOREIGN KEY ("product_option_value_id") REFERENCES "product_option_values" ("id"), FOREIGN KEY ("product_variant_id") REFERENCES "product_variants" ("id"), FOREIGN KEY ("product_id") REFERENCES "products" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "product_variant_images" ("product_variant_id" bigint, "image_id" bigint, FOREIGN KEY ("product_variant_id") REFERENCES "product_variants" ("id"), FOREIGN KEY ("image_id") REFERENCES "images" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "transactions" ("id" bigserial, "subtotal" bigint NOT NULL, "taxes" bigint NOT NULL, "shipping" bigint NOT NULL, "total" bigint NOT NULL, "braintree_id" text, "shippo_rate_id" text, "shippo_transaction_id" text, "user_id" bigint, "status" jsonb, PRIMARY KEY ("id"), FOREIGN KEY ("user_id") REFERENCES "users" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "transaction_address_infos" ("id" bigserial, "transaction_id" bigint NOT NULL, "billing_address_id" bigint NOT NULL, "shipping_address_id" bigint NOT NULL, PRIMARY KEY ("id"), FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id"), FOREIGN KEY ("billing_address_id") REFERENCES "addresses" ("id"), FOREIGN KEY ("shipping_address_id") REFERENCES "addresses" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "transaction_line_items" ("id" bigserial, "transaction_id" bigint NOT NULL, "product_variant_id" bigint NOT NULL, "price" bigint NOT NULL, "quantity" bigint NOT NULL, PRIMARY KEY ("id"), FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id"), FOREIGN KEY ("product_variant_id") REFERENCES "product_variants" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "transaction_statuses" ("id" bigserial, "created_at" timestamptz, "status" text, "carrier" text, "tracking_id" text, "transaction_id" bigint NOT NULL, PRIMARY KEY ("id"), FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id"))`,
+		},
+		[]string{
+			`DROP TABLE IF EXISTS "transaction_statuses"`,
+			`DROP TABLE IF EXISTS "transaction_line_items"`,
+			`DROP TABLE IF EXISTS "transaction_address_infos"`,
+			`DROP TABLE IF EXISTS "transactions"`,
+			`DROP TABLE IF EXISTS "product_variant_images"`,
+			`DROP TABLE IF EXISTS "product_variant_options"`,
+			`DROP TABLE IF EXISTS "product_variants"`,
+			`DROP TABLE IF EXISTS "product_option_values"`,
+			`DROP TABLE IF EXISTS "product_options"`,
+			`DROP TABLE IF EXISTS "product_images"`,
+			`DROP TABLE IF EXISTS "products"`,
+			`DROP TABLE IF EXISTS "addresses"`,
+			`DROP TABLE IF EXISTS "images"`,
+			`DROP TABLE IF EXISTS "users"`,
+		},
+	),
+	SQLMigration(2, "Add the inventory, shipping, tax and refund tables and columns",
+		[]string{
+			`CREATE TABLE IF NOT EXISTS "shipping_boxes" ("deleted_at" timestamptz, "id" bigserial, "name" text NOT NULL, "length" double precision NOT NULL, "width" double precision NOT NULL, "height" double precision NOT NULL, "weight" double precision NOT NULL, "max_weight" double precision NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "shipping_zones" ("deleted_at" timestamptz, "id" bigserial, "name" text NOT NULL, "country" text NOT NULL, "region" text, "postal_code_prefix" text, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "shipping_zone_rates" ("deleted_at" timestamptz, "id" bigserial, "shipping_zone_id" bigint NOT NULL, "carrier" text NOT NULL, "service" text NOT NULL, "duration_terms" text, "max_weight" double precision NOT NULL, "price" bigint NOT NULL, PRIMARY KEY ("id"), FOREIGN KEY ("shipping_zone_id") REFERENCES "shipping_zones" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "shipping_rate_quotes" ("id" text, "created_at" timestamptz NOT NULL, "carrier" text NOT NULL, "service" text NOT NULL, "duration_terms" text, "price" bigint NOT NULL, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "tax_rules" ("deleted_at" timestamptz, "id" bigserial, "name" text NOT NULL, "type" text, "country" text NOT NULL, "region" text, "postal_code_prefix" text, "rate" double precision NOT NULL, "exempt_tax_codes" text[], "taxes_shipping" boolean, PRIMARY KEY ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "inventory_adjustments" ("id" bigserial, "created_at" timestamptz NOT NULL, "product_variant_id" bigint NOT NULL, "quantity" bigint NOT NULL, "reason" text NOT NULL, "note" text, "user_id" bigint, "transaction_line_item_id" bigint, PRIMARY KEY ("id"), FOREIGN KEY ("product_variant_id") REFERENCES "product_variants" ("id"), FOREIGN KEY ("user_id") REFERENCES "users" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "transaction_refunds" ("id" bigserial, "created_at" timestamptz NOT NULL, "transaction_id" bigint NOT NULL, "gateway_id" text, "amount" bigint NOT NULL, "reason" text, "line_items" jsonb, "user_id" bigint, PRIMARY KEY ("id"), FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id"), FOREIGN KEY ("user_id") REFERENCES "users" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "transaction_shipments" ("id" bigserial, "transaction_id" bigint NOT NULL, "origin_id" bigint NOT NULL, "shipping" bigint NOT NULL, "shippo_rate_id" text, "shippo_transaction_id" text, PRIMARY KEY ("id"), FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id"), FOREIGN KEY ("origin_id") REFERENCES "addresses" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "transaction_parcels" ("id" bigserial, "transaction_shipment_id" bigint NOT NULL, "shipping_box_id" bigint, "length" double precision NOT NULL, "width" double precision NOT NULL, "height" double precision NOT NULL, "weight" double precision NOT NULL, "contents" jsonb NOT NULL, PRIMARY KEY ("id"), FOREIGN KEY ("transaction_shipment_id") REFERENCES "transaction_shipments" ("id"), FOREIGN KEY ("shipping_box_id") REFERENCES "shipping_boxes" ("id"))`,
+			`ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "created_at" timestamptz`,
+			`ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "updated_at" timestamptz`,
+			`ALTER TABLE "addresses" ADD COLUMN IF NOT EXISTS "created_at" timestamptz`,
+			`ALTER TABLE "addresses" ADD COLUMN IF NOT EXISTS "updated_at" timestamptz`,
+			`ALTER TABLE "addresses" ADD COLUMN IF NOT EXISTS "origin" boolean`,
+			`ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "created_at" timestamptz`,
+			`ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "updated_at" timestamptz`,
+			`ALTER TABLE "products" ADD COLUMN IF NOT EXISTS "tax_code" text`,
+			`ALTER TABLE "product_variants" ADD COLUMN IF NOT EXISTS "created_at" timestamptz`,
+			`ALTER TABLE "product_variants" ADD COLUMN IF NOT EXISTS "updated_at" timestamptz`,
+			`ALTER TABLE "product_variants" ADD COLUMN IF NOT EXISTS "stock" bigint NOT NULL DEFAULT 0`,
+			`ALTER TABLE "product_variants" ADD COLUMN IF NOT EXISTS "tax_code" text`,
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "created_at" timestamptz`,
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "updated_at" timestamptz`,
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "shipping_tax" bigint NOT NULL DEFAULT 0`,
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "shipping_tax_details" jsonb`,
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "tax_document_code" text`,
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "tax_committed" boolean`,
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "payment_status" text`,
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "disputed" boolean`,
+			`ALTER TABLE "transaction_line_items" ADD COLUMN IF NOT EXISTS "tax_code" text`,
+			`ALTER TABLE "transaction_line_items" ADD COLUMN IF NOT EXISTS "tax" bigint NOT NULL DEFAULT 0`,
+			`ALTER TABLE "transaction_line_items" ADD COLUMN IF NOT EXISTS "tax_details" jsonb`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "status"`,
+		},
+		[]string{
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "status" jsonb`,
+			`ALTER TABLE "transaction_line_items" DROP COLUMN IF EXISTS "tax_details"`,
+			`ALTER TABLE "transaction_line_items" DROP COLUMN IF EXISTS "tax"`,
+			`ALTER TABLE "transaction_line_items" DROP COLUMN IF EXISTS "tax_code"`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "disputed"`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "payment_status"`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "tax_committed"`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "tax_document_code"`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "shipping_tax_details"`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "shipping_tax"`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "updated_at"`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "created_at"`,
+			`ALTER TABLE "product_variants" DROP COLUMN IF EXISTS "tax_code"`,
+			`ALTER TABLE "product_variants" DROP COLUMN IF EXISTS "stock"`,
+			`ALTER TABLE "product_variants" DROP COLUMN IF EXISTS "updated_at"`,
+			`ALTER TABLE "product_variants" DROP COLUMN IF EXISTS "created_at"`,
+			`ALTER TABLE "products" DROP COLUMN IF EXISTS "tax_code"`,
+			`ALTER TABLE "products" DROP COLUMN IF EXISTS "updated_at"`,
+			`ALTER TABLE "products" DROP COLUMN IF EXISTS "created_at"`,
+			`ALTER TABLE "addresses" DROP COLUMN IF EXISTS "origin"`,
+			`ALTER TABLE "addresses" DROP COLUMN IF EXISTS "updated_at"`,
+			`ALTER TABLE "addresses" DROP COLUMN IF EXISTS "created_at"`,
+			`ALTER TABLE "users" DROP COLUMN IF EXISTS "updated_at"`,
+			`ALTER TABLE "users" DROP COLUMN IF EXISTS "created_at"`,
+			`DROP TABLE IF EXISTS "transaction_parcels"`,
+			`DROP TABLE IF EXISTS "transaction_shipments"`,
+			`DROP TABLE IF EXISTS "transaction_refunds"`,
+			`DROP TABLE IF EXISTS "inventory_adjustments"`,
+			`DROP TABLE IF EXISTS "tax_rules"`,
+			`DROP TABLE IF EXISTS "shipping_rate_quotes"`,
+			`DROP TABLE IF EXISTS "shipping_zone_rates"`,
+			`DROP TABLE IF EXISTS "shipping_zones"`,
+			`DROP TABLE IF EXISTS "shipping_boxes"`,
+		},
+	),
+	SQLMigration(3, "Index the transaction search and the sales analytics",
+		[]string{
+			`CREATE INDEX IF NOT EXISTS users_lower_email_idx ON users (lower(email))`,
+			`CREATE INDEX IF NOT EXISTS transactions_user_id_idx ON transactions (user_id)`,
+			`CREATE INDEX IF NOT EXISTS transactions_braintree_id_idx ON transactions (braintree_id)`,
+			`CREATE INDEX IF NOT EXISTS transactions_total_idx ON transactions (total)`,
+			`CREATE INDEX IF NOT EXISTS transactions_created_at_idx ON transactions (created_at)`,
+			`CREATE INDEX IF NOT EXISTS transaction_line_items_transaction_id_idx ON transaction_line_items (transaction_id)`,
+			`CREATE INDEX IF NOT EXISTS transaction_statuses_transaction_id_idx ON transaction_statuses (transaction_id, id)`,
+			`CREATE INDEX IF NOT EXISTS transaction_statuses_tracking_id_idx ON transaction_statuses (tracking_id)`,
+			`CREATE INDEX IF NOT EXISTS transaction_statuses_status_created_at_idx ON transaction_statuses (status, created_at)`,
+		},
+		[]string{
+			`DROP INDEX IF EXISTS transaction_statuses_status_created_at_idx`,
+			`DROP INDEX IF EXISTS transaction_statuses_tracking_id_idx`,
+			`DROP INDEX IF EXISTS transaction_statuses_transaction_id_idx`,
+			`DROP INDEX IF EXISTS transaction_line_items_transaction_id_idx`,
+			`DROP INDEX IF EXISTS transactions_created_at_idx`,
+			`DROP INDEX IF EXISTS transactions_total_idx`,
+			`DROP INDEX IF EXISTS transactions_braintree_id_idx`,
+			`DROP INDEX IF EXISTS transactions_user_id_idx`,
+			`DROP INDEX IF EXISTS users_lower_email_idx`,
+		},
+	),
+	// Transactions placed before they had timestamps are dated by the first status they were given.
+	SQLMigration(4, "Backfill the timestamps of transactions",
+		[]string{
+			`UPDATE "transactions" SET "created_at" = placed."created_at", "updated_at" = coalesce("transactions"."updated_at", placed."created_at")
+			FROM (
+				SELECT "transaction_id", min("created_at") AS "created_at" FROM "transaction_statuses" GROUP BY "transaction_id"
+			) AS placed
+			WHERE placed."transaction_id" = "transactions"."id" AND "transactions"."created_at" IS NULL`,
+		},
+		[]string{},
+	),
+	SQLMigration(5, "Add idempotency keys to transactions",
+		[]string{
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "idempotency_key" text`,
+			`ALTER TABLE "transactions" ADD COLUMN IF NOT EXISTS "idempotency_fingerprint" text`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS transactions_idempotency_key_idx ON transactions (idempotency_key)`,
+		},
+		[]string{
+			`DROP INDEX IF EXISTS transactions_idempotency_key_idx`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "idempotency_fingerprint"`,
+			`ALTER TABLE "transactions" DROP COLUMN IF EXISTS "idempotency_key"`,
+		},
+	),
+	SQLMigration(6, "Create the checkout quotes table",
+		[]string{
+			`CREATE TABLE IF NOT EXISTS "checkout_quotes" ("id" text, "created_at" timestamptz NOT NULL, "expires_at" timestamptz NOT NULL, "user_id" bigint, "billing_address_id" bigint NOT NULL, "shipping_address_id" bigint NOT NULL, "line_items" jsonb NOT NULL, "shipments" jsonb NOT NULL, "subtotal" bigint NOT NULL, "taxes" bigint NOT NULL, "shipping" bigint NOT NULL, "shipping_tax" bigint NOT NULL, "shipping_tax_details" jsonb, "total" bigint NOT NULL, "tax_document_code" text, "transaction_id" bigint, PRIMARY KEY ("id"), FOREIGN KEY ("user_id") REFERENCES "users" ("id"), FOREIGN KEY ("billing_address_id") REFERENCES "addresses" ("id"), FOREIGN KEY ("shipping_address_id") REFERENCES "addresses" ("id"), FOREIGN KEY ("transaction_id") REFERENCES "transactions" ("id"))`,
+		},
+		[]string{
+			`DROP TABLE IF EXISTS "checkout_quotes"`,
+		},
+	),
+	SQLMigration(7, "Create the carts tables",
+		[]string{
+			`CREATE TABLE IF NOT EXISTS "carts" ("id" bigserial, "created_at" timestamptz, "updated_at" timestamptz, "token" text UNIQUE, "user_id" bigint UNIQUE, PRIMARY KEY ("id"), FOREIGN KEY ("user_id") REFERENCES "users" ("id"))`,
+			`CREATE TABLE IF NOT EXISTS "cart_items" ("id" bigserial, "cart_id" bigint NOT NULL, "product_variant_id" bigint NOT NULL, "quantity" bigint NOT NULL, PRIMARY KEY ("id"), FOREIGN KEY ("cart_id") REFERENCES "carts" ("id"), FOREIGN KEY ("product_variant_id") REFERENCES "product_variants" ("id"))`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS cart_items_cart_id_product_variant_id_idx ON cart_items (cart_id, product_variant_id)`,
+		},
+		[]string{
+			`DROP TABLE IF EXISTS "cart_items"`,
+			`DROP TABLE IF EXISTS "carts"`,
+		},
+	),
+	SQLMigration(8, "Track abandoned cart reminders",
+		[]string{
+			`ALTER TABLE "carts" ADD COLUMN IF NOT EXISTS "reminder_sent_at" timestamptz`,
+			`CREATE INDEX IF NOT EXISTS carts_updated_at_idx ON carts (updated_at) WHERE user_id IS NOT NULL`,
+		},
+		[]string{
+			`DROP INDEX IF EXISTS carts_updated_at_idx`,
+			`ALTER TABLE "carts" DROP COLUMN IF EXISTS "reminder_sent_at"`,
+		},
+	),
+	SQLMigration(9, "Expire shipping rate quotes",
+		[]string{
+			`ALTER TABLE "shipping_rate_quotes" ADD COLUMN IF NOT EXISTS "expires_at" timestamptz`,
+			`UPDATE "shipping_rate_quotes" SET "expires_at" = "created_at" + interval '1 day' WHERE "expires_at" IS NULL`,
+			`ALTER TABLE "shipping_rate_quotes" ALTER COLUMN "expires_at" SET NOT NULL`,
+			`CREATE INDEX IF NOT EXISTS shipping_rate_quotes_expires_at_idx ON shipping_rate_quotes (expires_at)`,
+			`CREATE INDEX IF NOT EXISTS transaction_shipments_shippo_rate_id_idx ON transaction_shipments (shippo_rate_id)`,
+		},
+		[]string{
+			`DROP INDEX IF EXISTS transaction_shipments_shippo_rate_id_idx`,
+			`DROP INDEX IF EXISTS shipping_rate_quotes_expires_at_idx`,
+			`ALTER TABLE "shipping_rate_quotes" DROP COLUMN IF EXISTS "expires_at"`,
+		},
+	),
+	SQLMigration(10, "Record each gateway refund once",
+		[]string{
+			`DELETE FROM "transaction_refunds" AS "duplicate" USING "transaction_refunds" AS "original" WHERE "duplicate"."gateway_id" = "original"."gateway_id" AND "duplicate"."id" > "original"."id"`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS transaction_refunds_gateway_id_idx ON transaction_refunds (gateway_id)`,
+		},
+		[]string{
+			`DROP INDEX IF EXISTS transaction_refunds_gateway_id_idx`,
+		},
+	),
+	SQLMigration(11, "Bind shipping rate quotes to what they were rated for",
+		[]string{
+			`ALTER TABLE "shipping_rate_quotes" ADD COLUMN IF NOT EXISTS "origin_id" bigint REFERENCES "addresses" ("id")`,
+			`ALTER TABLE "shipping_rate_quotes" ADD COLUMN IF NOT EXISTS "destination" text`,
+			`ALTER TABLE "shipping_rate_quotes" ADD COLUMN IF NOT EXISTS "parcel_hash" text`,
+			// Quotes from before this migration cannot be checked at checkout, so they can no longer be selected.
+			`UPDATE "shipping_rate_quotes" SET "expires_at" = now() WHERE "expires_at" > now()`,
+		},
+		[]string{
+			`ALTER TABLE "shipping_rate_quotes" DROP COLUMN IF EXISTS "parcel_hash"`,
+			`ALTER TABLE "shipping_rate_quotes" DROP COLUMN IF EXISTS "destination"`,
+			`ALTER TABLE "shipping_rate_quotes" DROP COLUMN IF EXISTS "origin_id"`,
+		},
+	),
+}
@@ -7,6 +7,8 @@ import (
 
 type User struct {
 	ID        int
+	CreatedAt time.Time
+	UpdatedAt time.Time
 	Email     string `pg:",unique,notnull"`
 	Password  string `pg:",notnull"`
 	Role      string
@@ -16,6 +18,8 @@ type User struct {
 type Address struct {
 	DeletedAt  time.Time `pg:",soft_delete"`
 	ID         int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 	Name       string `pg:",notnull"`
 	Line1      string `pg:",notnull"`
 	Line2      string
@@ -26,6 +30,8 @@ type Address struct {
 	Country    string `pg:",notnull"`
 	UserID     int
 	User       *User
+	// Origin addresses are the store locations product variants ship from.
+	Origin bool
 }
 
 func (address Address) String() string {
@@ -49,11 +55,14 @@ type Image struct {
 type Product struct {
 	DeletedAt       time.Time `pg:",soft_delete"`
 	ID              int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 	Slug            string `pg:",unique,notnull"`
 	Name            string `pg:",notnull"`
 	Description     string `pg:",notnull"`
 	Details         string
 	Published       bool
+	TaxCode         string
 	ProductImages   []*ProductImage   `pg:"fk:product_id"`
 	ProductOptions  []*ProductOption  `pg:"fk:product_id"`
 	ProductVariants []*ProductVariant `pg:"fk:product_id"`
@@ -86,18 +95,39 @@ type ProductOptionValue struct {
 type ProductVariant struct {
 	DeletedAt       time.Time `pg:",soft_delete"`
 	ID              int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 	Name            string
 	Price           int                     `pg:",notnull"`
 	Length          float64                 `pg:",notnull"`
 	Width           float64                 `pg:",notnull"`
 	Height          float64                 `pg:",notnull"`
 	Weight          float64                 `pg:",notnull"`
+	Stock           int                     `pg:",notnull,use_zero"`
 	SelectedOptions []*ProductVariantOption `pg:"fk:product_variant_id"`
 	ProductID       int                     `pg:",notnull"`
 	Product         *Product
 	ShipsFromID     int
 	ShipsFrom       *Address
 	Images          []*ProductVariantImage
+	// Overrides the product's tax code.
+	TaxCode string
+}
+
+// An append-only record of a change to a product variant's stock. The Stock column of the
+// variant is the running balance of its adjustments.
+type InventoryAdjustment struct {
+	ID               int
+	CreatedAt        time.Time `pg:",notnull"`
+	ProductVariantID int       `pg:",notnull"`
+	ProductVariant   *ProductVariant
+	Quantity         int    `pg:",notnull"`
+	Reason           string `pg:",notnull"`
+	Note             string
+	UserID           int
+	User             *User
+	// Not a relation so the ledger outlives the line items of failed checkouts.
+	TransactionLineItemID int
 }
 
 type ProductVariantImage struct {
@@ -119,6 +149,8 @@ type ProductVariantOption struct {
 
 type Transaction struct {
 	ID                  int
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
 	Subtotal            int `pg:",notnull"`
 	Taxes               int `pg:",notnull"`
 	Shipping            int `pg:",notnull"`
@@ -130,7 +162,84 @@ type Transaction struct {
 	User                *User
 	Addresses           *TransactionAddressInfo `pg:"fk:transaction_id"`
 	LineItems           []*TransactionLineItem  `pg:"fk:transaction_id"`
-	Status              []*TransactionStatus    `pg:"fk:transasction_id"`
+	Status              []*TransactionStatus    `pg:"fk:transaction_id"`
+	Shipments           []*TransactionShipment  `pg:"fk:transaction_id"`
+	ShippingTax         int                     `pg:",notnull,use_zero"`
+	ShippingTaxDetails  []*TaxDetail
+	// The code the taxes were saved under with the tax provider, committed once the order is paid.
+	TaxDocumentCode string
+	TaxCommitted    bool
+	// The status of the payment with the payment gateway when it was last charged or changed.
+	PaymentStatus string
+	// Set when the customer opens a dispute for the payment.
+	Disputed bool
+	// The key the client sent with the checkout that created the transaction. Retrying the checkout
+	// with the same key returns this transaction instead of charging again.
+	IdempotencyKey string
+	// A hash of the checkout the idempotency key was first used for.
+	IdempotencyFingerprint string
+}
+
+// Prices, taxes and shipping locked in for a checkout until the quote expires. Amounts are in cents.
+type CheckoutQuote struct {
+	ID                 string
+	CreatedAt          time.Time `pg:",notnull"`
+	ExpiresAt          time.Time `pg:",notnull"`
+	UserID             int
+	BillingAddressID   int                      `pg:",notnull"`
+	ShippingAddressID  int                      `pg:",notnull"`
+	LineItems          []*CheckoutQuoteLineItem `pg:",notnull"`
+	Shipments          []*CheckoutQuoteShipment `pg:",notnull"`
+	Subtotal           int                      `pg:",notnull,use_zero"`
+	Taxes              int                      `pg:",notnull,use_zero"`
+	Shipping           int                      `pg:",notnull,use_zero"`
+	ShippingTax        int                      `pg:",notnull,use_zero"`
+	ShippingTaxDetails []*TaxDetail
+	Total              int `pg:",notnull,use_zero"`
+	// The code the taxes were saved under with the tax provider.
+	TaxDocumentCode string
+	// Set once a transaction is placed with the quote, so it is only charged once.
+	TransactionID int
+}
+
+// A shopping cart kept on the server. Guest carts are found by their token, signed in customers have
+// a single cart tied to their account instead.
+type Cart struct {
+	ID        int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Token     string `pg:",unique"`
+	UserID    int    `pg:",unique"`
+	User      *User
+	Items     []*CartItem `pg:"fk:cart_id"`
+	// When the customer was last reminded of the cart. A cart that changed since can be reminded of again.
+	ReminderSentAt time.Time
+}
+
+type CartItem struct {
+	ID               int
+	CartID           int `pg:",notnull"`
+	ProductVariantID int `pg:",notnull"`
+	ProductVariant   *ProductVariant
+	Quantity         int `pg:",notnull"`
+}
+
+// A line of a checkout quote. Stored as JSON.
+type CheckoutQuoteLineItem struct {
+	ProductVariantID int
+	Quantity         int
+	Price            int
+	TaxCode          string
+	Tax              int
+	TaxDetails       []*TaxDetail
+}
+
+// A shipment of a checkout quote with the rate selected for it. Stored as JSON.
+type CheckoutQuoteShipment struct {
+	OriginID     int
+	Shipping     int
+	ShippoRateID string
+	Parcels      []*TransactionParcel
 }
 
 type TransactionAddressInfo struct {
@@ -151,6 +260,17 @@ type TransactionLineItem struct {
 	ProductVariant   *ProductVariant
 	Price            int `pg:",notnull"`
 	Quantity         int `pg:",notnull"`
+	TaxCode          string
+	Tax              int `pg:",notnull,use_zero"`
+	TaxDetails       []*TaxDetail
+}
+
+// A tax charged on a line item or on shipping. Stored as JSON.
+type TaxDetail struct {
+	Name string
+	Type string
+	Rate float64
+	Tax  int
 }
 
 type TransactionStatus struct {
@@ -162,3 +282,127 @@ type TransactionStatus struct {
 	TransactionID int `pg:",notnull"`
 	Transaction   *Transaction
 }
+
+// Money returned to the customer for a transaction. Amounts are in cents.
+type TransactionRefund struct {
+	ID            int
+	CreatedAt     time.Time `pg:",notnull"`
+	TransactionID int       `pg:",notnull"`
+	Transaction   *Transaction
+	// The ID of the refund with the payment gateway.
+	GatewayID string
+	Amount    int `pg:",notnull"`
+	Reason    string
+	LineItems []*RefundLineItem
+	UserID    int
+	User      *User
+}
+
+// The units of a line item a refund was for.
+type RefundLineItem struct {
+	TransactionLineItemID int
+	Quantity              int
+	Amount                int
+}
+
+// A package of the transaction's line items that ships from a single origin.
+type TransactionShipment struct {
+	ID                  int
+	TransactionID       int `pg:",notnull"`
+	Transaction         *Transaction
+	OriginID            int `pg:",notnull"`
+	Origin              *Address
+	Shipping            int `pg:",notnull,use_zero"`
+	ShippoRateID        string
+	ShippoTransactionID string
+	Parcels             []*TransactionParcel `pg:"fk:transaction_shipment_id"`
+}
+
+// A box size the store packs orders into. Dimensions are in inches and weights in ounces.
+type ShippingBox struct {
+	DeletedAt time.Time `pg:",soft_delete"`
+	ID        int
+	Name      string  `pg:",notnull"`
+	Length    float64 `pg:",notnull"`
+	Width     float64 `pg:",notnull"`
+	Height    float64 `pg:",notnull"`
+	Weight    float64 `pg:",notnull,use_zero"`
+	MaxWeight float64 `pg:",notnull"`
+}
+
+// A parcel of a shipment's packing plan. The label is purchased for the same parcels the shipment was rated with.
+type TransactionParcel struct {
+	ID                    int
+	TransactionShipmentID int `pg:",notnull"`
+	TransactionShipment   *TransactionShipment
+	// Zero when the item did not fit any box and ships in its own packaging.
+	ShippingBoxID int
+	ShippingBox   *ShippingBox
+	Length        float64          `pg:",notnull"`
+	Width         float64          `pg:",notnull"`
+	Height        float64          `pg:",notnull"`
+	Weight        float64          `pg:",notnull"`
+	Contents      []*ParcelContent `pg:",notnull"`
+}
+
+// Stored as JSON on the parcel.
+type ParcelContent struct {
+	ProductVariantID int
+	Quantity         int
+}
+
+// A destination the table rate shipping provider rates to. Empty regions and postal code prefixes
+// match any destination in the country, and the most specific matching zone is used.
+type ShippingZone struct {
+	DeletedAt        time.Time `pg:",soft_delete"`
+	ID               int
+	Name             string `pg:",notnull"`
+	Country          string `pg:",notnull"`
+	Region           string
+	PostalCodePrefix string
+	Rates            []*ShippingZoneRate `pg:"fk:shipping_zone_id"`
+}
+
+// The price in cents to ship a parcel weighing up to MaxWeight ounces to the zone with a service.
+type ShippingZoneRate struct {
+	DeletedAt      time.Time `pg:",soft_delete"`
+	ID             int
+	ShippingZoneID int `pg:",notnull"`
+	ShippingZone   *ShippingZone
+	Carrier        string `pg:",notnull"`
+	Service        string `pg:",notnull"`
+	DurationTerms  string
+	MaxWeight      float64 `pg:",notnull"`
+	Price          int     `pg:",notnull,use_zero"`
+}
+
+// A rate quoted by the table rate shipping provider, so checkout charges what was quoted.
+type ShippingRateQuote struct {
+	ID            string
+	CreatedAt     time.Time `pg:",notnull"`
+	ExpiresAt     time.Time `pg:",notnull"`
+	Carrier       string    `pg:",notnull"`
+	Service       string    `pg:",notnull"`
+	DurationTerms string
+	Price         int `pg:",notnull,use_zero"`
+	// The origin address, destination and hash of the parcels the quote was rated for.
+	OriginID    int
+	Destination string
+	ParcelHash  string
+}
+
+// A tax rate the local tax provider charges in a country. Empty regions and postal code prefixes
+// match the whole country. Rates are fractions, so 0.065 is 6.5%.
+type TaxRule struct {
+	DeletedAt        time.Time `pg:",soft_delete"`
+	ID               int
+	Name             string `pg:",notnull"`
+	Type             string
+	Country          string `pg:",notnull"`
+	Region           string
+	PostalCodePrefix string
+	Rate             float64 `pg:",notnull"`
+	// Tax codes of lines the rule doesn't apply to.
+	ExemptTaxCodes []string `pg:",array"`
+	TaxesShipping  bool
+}
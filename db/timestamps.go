@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Sets the creation and modification times of a model that is being inserted.
+func touchCreated(createdAt *time.Time, updatedAt *time.Time) {
+	now := time.Now()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	*updatedAt = now
+}
+
+func (user *User) BeforeInsert(ctx context.Context) (context.Context, error) {
+	touchCreated(&user.CreatedAt, &user.UpdatedAt)
+	return ctx, nil
+}
+
+func (user *User) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	user.UpdatedAt = time.Now()
+	return ctx, nil
+}
+
+func (address *Address) BeforeInsert(ctx context.Context) (context.Context, error) {
+	touchCreated(&address.CreatedAt, &address.UpdatedAt)
+	return ctx, nil
+}
+
+func (address *Address) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	address.UpdatedAt = time.Now()
+	return ctx, nil
+}
+
+func (product *Product) BeforeInsert(ctx context.Context) (context.Context, error) {
+	touchCreated(&product.CreatedAt, &product.UpdatedAt)
+	return ctx, nil
+}
+
+func (product *Product) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	product.UpdatedAt = time.Now()
+	return ctx, nil
+}
+
+func (variant *ProductVariant) BeforeInsert(ctx context.Context) (context.Context, error) {
+	touchCreated(&variant.CreatedAt, &variant.UpdatedAt)
+	return ctx, nil
+}
+
+func (variant *ProductVariant) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	variant.UpdatedAt = time.Now()
+	return ctx, nil
+}
+
+func (transaction *Transaction) BeforeInsert(ctx context.Context) (context.Context, error) {
+	touchCreated(&transaction.CreatedAt, &transaction.UpdatedAt)
+	return ctx, nil
+}
+
+func (transaction *Transaction) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	transaction.UpdatedAt = time.Now()
+	return ctx, nil
+}
+
+func (cart *Cart) BeforeInsert(ctx context.Context) (context.Context, error) {
+	touchCreated(&cart.CreatedAt, &cart.UpdatedAt)
+	return ctx, nil
+}
+
+func (cart *Cart) BeforeUpdate(ctx context.Context) (context.Context, error) {
+	cart.UpdatedAt = time.Now()
+	return ctx, nil
+}
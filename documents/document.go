@@ -0,0 +1,139 @@
+package documents
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	core "github.com/jacob-ebey/graphql-core"
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+// A line item as it is printed on a document. Amounts are in cents.
+type LineItem struct {
+	Name     string
+	Options  []string
+	Quantity int
+	Price    int
+	Tax      int
+}
+
+// The order a document is printed for. Amounts are in cents.
+type Order struct {
+	ID              int
+	PlacedAt        time.Time
+	LineItems       []*LineItem
+	Subtotal        int
+	Taxes           int
+	Shipping        int
+	Total           int
+	BillingAddress  *db.Address
+	ShippingAddress *db.Address
+}
+
+const (
+	pageMargin = 15.0
+	lineHeight = 6.0
+)
+
+func formatCents(cents int) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
+}
+
+func addressLines(address *db.Address) []string {
+	if address == nil {
+		return []string{}
+	}
+
+	lines := []string{}
+	for _, line := range []string{
+		address.Name,
+		address.Line1,
+		address.Line2,
+		address.Line3,
+		strings.TrimSpace(address.City + ", " + address.Region + " " + address.PostalCode),
+		address.Country,
+	} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func newDocument(title string, order *Order) (*gofpdf.Fpdf, func(string) string) {
+	pdf := gofpdf.New("P", "mm", "Letter", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+	pdf.SetTitle(fmt.Sprintf("%s #%d", title, order.ID), true)
+	pdf.AddPage()
+
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.CellFormat(0, 10, translate(title), "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, lineHeight, fmt.Sprintf("Order #%d", order.ID), "", 1, "L", false, 0, "")
+	if !order.PlacedAt.IsZero() {
+		pdf.CellFormat(0, lineHeight, "Placed "+order.PlacedAt.Format("January 2, 2006"), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(lineHeight)
+
+	return pdf, translate
+}
+
+// Prints the addresses side by side.
+func writeAddresses(pdf *gofpdf.Fpdf, translate func(string) string, labels []string, addresses []*db.Address) {
+	width, _ := pdf.GetPageSize()
+	columnWidth := (width - 2*pageMargin) / float64(len(addresses))
+
+	pdf.SetFont("Helvetica", "B", 11)
+	for _, label := range labels {
+		pdf.CellFormat(columnWidth, lineHeight, translate(label), "", 0, "L", false, 0, "")
+	}
+	pdf.Ln(lineHeight)
+
+	pdf.SetFont("Helvetica", "", 11)
+	columns := make([][]string, len(addresses))
+	rows := 0
+	for index, address := range addresses {
+		columns[index] = addressLines(address)
+		if len(columns[index]) > rows {
+			rows = len(columns[index])
+		}
+	}
+	for row := 0; row < rows; row++ {
+		for _, lines := range columns {
+			line := ""
+			if row < len(lines) {
+				line = lines[row]
+			}
+			pdf.CellFormat(columnWidth, lineHeight, translate(line), "", 0, "L", false, 0, "")
+		}
+		pdf.Ln(lineHeight)
+	}
+	pdf.Ln(lineHeight)
+}
+
+func output(pdf *gofpdf.Fpdf) ([]byte, error) {
+	buffer := bytes.Buffer{}
+	if err := pdf.Output(&buffer); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not create document.",
+			InternalError: err,
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package documents
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+// Creates an invoice with the line items, taxes, shipping and addresses of the order.
+func NewInvoice(order *Order) ([]byte, error) {
+	pdf, translate := newDocument("Invoice", order)
+
+	writeAddresses(pdf, translate, []string{"Bill to", "Ship to"}, []*db.Address{order.BillingAddress, order.ShippingAddress})
+
+	widths := []float64{95, 20, 30, 40}
+	pdf.SetFont("Helvetica", "B", 11)
+	for index, header := range []string{"Item", "Quantity", "Price", "Amount"} {
+		align := "R"
+		if index == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[index], lineHeight+1, header, "B", 0, align, false, 0, "")
+	}
+	pdf.Ln(lineHeight + 1)
+
+	pdf.SetFont("Helvetica", "", 11)
+	for _, lineItem := range order.LineItems {
+		name := lineItem.Name
+		if len(lineItem.Options) > 0 {
+			name += " (" + strings.Join(lineItem.Options, ", ") + ")"
+		}
+
+		pdf.CellFormat(widths[0], lineHeight, translate(name), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], lineHeight, strconv.Itoa(lineItem.Quantity), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[2], lineHeight, formatCents(lineItem.Price), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], lineHeight, formatCents(lineItem.Price*lineItem.Quantity), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(lineHeight / 2)
+
+	writeTotal(pdf, widths, "Subtotal", order.Subtotal, false)
+	writeTotal(pdf, widths, "Shipping", order.Shipping, false)
+	writeTotal(pdf, widths, "Taxes", order.Taxes, false)
+	writeTotal(pdf, widths, "Total", order.Total, true)
+
+	return output(pdf)
+}
+
+func writeTotal(pdf *gofpdf.Fpdf, widths []float64, label string, amount int, bold bool) {
+	style := ""
+	border := ""
+	if bold {
+		style = "B"
+		border = "T"
+	}
+
+	pdf.SetFont("Helvetica", style, 11)
+	pdf.CellFormat(widths[0]+widths[1], lineHeight, "", "", 0, "L", false, 0, "")
+	pdf.CellFormat(widths[2], lineHeight, label, border, 0, "R", false, 0, "")
+	pdf.CellFormat(widths[3], lineHeight, formatCents(amount), border, 1, "R", false, 0, "")
+}
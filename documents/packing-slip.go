@@ -0,0 +1,40 @@
+package documents
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf/contrib/barcode"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+// Creates a packing slip with the items to pack and a barcode of the order ID.
+func NewPackingSlip(order *Order) ([]byte, error) {
+	pdf, translate := newDocument("Packing Slip", order)
+
+	width, _ := pdf.GetPageSize()
+	key := barcode.RegisterCode128(pdf, strconv.Itoa(order.ID))
+	barcode.Barcode(pdf, key, width-pageMargin-60, pageMargin, 60, 15, false)
+
+	writeAddresses(pdf, translate, []string{"Ship to"}, []*db.Address{order.ShippingAddress})
+
+	widths := []float64{20, 165}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(widths[0], lineHeight+1, "Quantity", "B", 0, "L", false, 0, "")
+	pdf.CellFormat(widths[1], lineHeight+1, "Item", "B", 1, "L", false, 0, "")
+
+	for _, lineItem := range order.LineItems {
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(widths[0], lineHeight, strconv.Itoa(lineItem.Quantity), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], lineHeight, translate(lineItem.Name), "", 1, "L", false, 0, "")
+
+		if len(lineItem.Options) > 0 {
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.CellFormat(widths[0], lineHeight, "", "", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[1], lineHeight, translate(strings.Join(lineItem.Options, ", ")), "", 1, "L", false, 0, "")
+		}
+	}
+
+	return output(pdf)
+}
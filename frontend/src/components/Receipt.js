@@ -96,6 +96,18 @@ export default function Receipt({ receipt }) {
           </tr>
         </tbody>
       </Table>
+
+      {receipt.invoiceUrl && (
+        <p>
+          <a
+            rel="noopener noreferrer"
+            target="_blank"
+            href={receipt.invoiceUrl}
+          >
+            Download invoice
+          </a>
+        </p>
+      )}
     </div>
   );
 }
@@ -107,6 +119,7 @@ Receipt.fragments = {
       taxes
       shipping
       total
+      invoiceUrl
       lineItems {
         price
         quantity
@@ -130,6 +143,7 @@ Receipt.fragments = {
       taxes
       shipping
       total
+      invoiceUrl
       lineItems {
         price
         quantity
@@ -9,6 +9,7 @@ import Tabs from "react-bootstrap/Tabs";
 import Error from "../components/Error";
 import Login from "../components/Login";
 import Signup from "../components/Signup";
+import { cartToken, forgetCartToken } from "../resolvers/cart";
 
 const RESPONSE = gql`
   fragment LoginSignupPopupResponse on AuthResponse {
@@ -18,8 +19,12 @@ const RESPONSE = gql`
 `;
 
 const LOGIN = gql`
-  mutation LoginSignupPopupLogin($email: String!, $password: String!) {
-    login: signIn(email: $email, password: $password) {
+  mutation LoginSignupPopupLogin(
+    $email: String!
+    $password: String!
+    $cartToken: String
+  ) {
+    login: signIn(email: $email, password: $password, cartToken: $cartToken) {
       ...LoginSignupPopupResponse
     }
   }
@@ -45,11 +50,13 @@ const SIGNUP = gql`
     $email: String!
     $password: String!
     $confirmPassword: String!
+    $cartToken: String
   ) {
     signup: signUp(
       email: $email
       password: $password
       confirmPassword: $confirmPassword
+      cartToken: $cartToken
     ) {
       ...LoginSignupPopupResponse
     }
@@ -94,11 +101,13 @@ function LoginSignupPopup({ show, onHide }) {
     const result = await login({
       variables: {
         email: creds.email,
-        password: creds.password
+        password: creds.password,
+        cartToken: cartToken()
       }
     });
 
     if (result.data && result.data.login) {
+      forgetCartToken();
       setAuth(result.data.login, creds.stayLoggedIn);
     }
   });
@@ -112,11 +121,13 @@ function LoginSignupPopup({ show, onHide }) {
       variables: {
         email: creds.email,
         password: creds.password,
-        confirmPassword: creds.confirmPassword
+        confirmPassword: creds.confirmPassword,
+        cartToken: cartToken()
       }
     });
 
     if (result.data && result.data.signup) {
+      forgetCartToken();
       setAuth(result.data.signup, creds.stayLoggedIn);
     }
   });
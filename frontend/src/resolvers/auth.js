@@ -1,5 +1,7 @@
 import decode from "jwt-decode";
 
+import { emptyCart } from "./cart";
+
 let saved = JSON.parse(localStorage.getItem("auth") || "null");
 
 export function auth() {
@@ -48,7 +50,8 @@ export function logout(_, __, { cache }) {
   localStorage.removeItem("auth");
   saved = null;
 
-  cache.writeData({ data: { auth: auth() } });
+  // The cart belongs to the account that signed out.
+  cache.writeData({ data: { auth: auth(), cart: emptyCart() } });
 
   return true;
 }
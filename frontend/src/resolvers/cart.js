@@ -1,78 +1,148 @@
-export function cart() {
-  const saved = JSON.parse(localStorage.getItem("cart") || "null");
-
-  return (
-    saved || {
-      variants: [],
-      __typename: "CartState"
+import { gql } from "apollo-boost";
+
+// The cart is kept on the server so it follows the customer across devices. Guests are given a
+// token for their cart, which is moved into their account when they sign in.
+
+const SERVER_CART = gql`
+  fragment ServerCart on Cart {
+    id
+    token
+    items {
+      variantId
+      quantity
     }
-  );
-}
+  }
+`;
 
-export function clearCart(_, __, { cache }) {
-  const existing = cart();
+const CART_QUERY = gql`
+  query ResolverCart($token: String) {
+    cart(token: $token) {
+      ...ServerCart
+    }
+  }
+  ${SERVER_CART}
+`;
+
+const UPDATE_CART_ITEM = gql`
+  mutation ResolverUpdateCartItem(
+    $token: String
+    $variantId: Int!
+    $quantity: Int!
+  ) {
+    cart: updateCartItem(
+      token: $token
+      variantId: $variantId
+      quantity: $quantity
+    ) {
+      ...ServerCart
+    }
+  }
+  ${SERVER_CART}
+`;
 
-  const newCart = {
-    ...existing,
-    variants: []
-  };
+const REMOVE_CART_ITEM = gql`
+  mutation ResolverRemoveCartItem($token: String, $variantId: Int!) {
+    cart: removeCartItem(token: $token, variantId: $variantId) {
+      ...ServerCart
+    }
+  }
+  ${SERVER_CART}
+`;
 
-  localStorage.setItem("cart", JSON.stringify(newCart));
-  cache.writeData({ data: { cart: newCart } });
+const EMPTY_CART = gql`
+  mutation ResolverEmptyCart($token: String) {
+    cart: emptyCart(token: $token) {
+      ...ServerCart
+    }
+  }
+  ${SERVER_CART}
+`;
 
-  return true;
+export function cartToken() {
+  return localStorage.getItem("cartToken") || null;
 }
 
-export function removeFromCart(_, { variantId }, { cache }) {
-  const existing = cart();
+export function forgetCartToken() {
+  localStorage.removeItem("cartToken");
+}
 
-  const newCart = {
-    ...existing,
-    variants: existing.variants.filter(
-      variant => variant.variantId !== variantId
-    )
+export function emptyCart() {
+  return {
+    variants: [],
+    __typename: "CartState"
   };
+}
 
-  localStorage.setItem("cart", JSON.stringify(newCart));
-  cache.writeData({ data: { cart: newCart } });
+function toCartState(serverCart) {
+  if (!serverCart) {
+    return emptyCart();
+  }
 
-  return true;
-}
+  if (serverCart.token) {
+    localStorage.setItem("cartToken", serverCart.token);
+  }
 
-export function changeCartQuantity(_, { variantId, quantity }, { cache }) {
-  const existing = cart();
+  return {
+    variants: serverCart.items.map(item => ({
+      variantId: item.variantId,
+      quantity: item.quantity,
+      __typename: "CartVariant"
+    })),
+    __typename: "CartState"
+  };
+}
 
-  let found = false;
-  const variants = existing.variants.map(variant => {
-    if (variant.variantId === variantId) {
-      found = true;
+async function saveCart(client, cache, mutation, variables) {
+  const { data } = await client.mutate({
+    mutation,
+    variables: { token: cartToken(), ...variables }
+  });
 
-      const newQuantity = variant.quantity + quantity;
+  const newCart = toCartState(data && data.cart);
+  cache.writeData({ data: { cart: newCart } });
 
-      return {
-        ...variant,
-        quantity: newQuantity < 1 ? 1 : newQuantity
-      };
-    }
+  return true;
+}
 
-    return variant;
+export async function cart(_, __, { client }) {
+  const { data } = await client.query({
+    query: CART_QUERY,
+    variables: { token: cartToken() },
+    // Not cached, so it is not confused with the local cart field.
+    fetchPolicy: "no-cache"
   });
 
-  if (!found) {
-    variants.push({
-      variantId,
-      quantity: quantity < 1 ? 1 : quantity,
-      __typename: "CartVariant"
-    });
+  return toCartState(data && data.cart);
+}
+
+export async function clearCart(_, __, { cache, client }) {
+  try {
+    return await saveCart(client, cache, EMPTY_CART, {});
+  } catch (err) {
+    // There is no cart to empty yet.
+    cache.writeData({ data: { cart: emptyCart() } });
+    return true;
   }
+}
 
-  const newCart = {
-    ...existing,
-    variants
-  };
+export function removeFromCart(_, { variantId }, { cache, client }) {
+  return saveCart(client, cache, REMOVE_CART_ITEM, { variantId });
+}
 
-  localStorage.setItem("cart", JSON.stringify(newCart));
-  cache.writeData({ data: { cart: newCart } });
+export async function changeCartQuantity(
+  _,
+  { variantId, quantity },
+  { cache, client }
+) {
+  const existing = await cart(null, null, { client });
+  const found = existing.variants.find(
+    variant => variant.variantId === variantId
+  );
 
-  return true;
+  const newQuantity = (found ? found.quantity : 0) + quantity;
+
+  return saveCart(client, cache, UPDATE_CART_ITEM, {
+    variantId,
+    quantity: newQuantity < 1 ? 1 : newQuantity
+  });
 }
@@ -104,6 +104,7 @@ const CHECKOUT = gql`
     $lineItems: [CartInput!]!
     $shippingRateId: String!
     $total: Int!
+    $idempotencyKey: String
   ) {
     receipt: submitBraintreeTransaction(
       braintreeNonce: $braintreeNonce
@@ -116,6 +117,7 @@ const CHECKOUT = gql`
       billingAddressId: $billingAddressId
       billingAddress: $billingAddress
       saveBillingAddress: $saveBillingAddress
+      idempotencyKey: $idempotencyKey
     ) {
       id
       ...ReceiptReceipt
@@ -124,6 +126,13 @@ const CHECKOUT = gql`
   ${Receipt.fragments.receipt}
 `;
 
+// A random key for the checkout, so a double submit or a retry does not charge twice.
+function newIdempotencyKey() {
+  return Array.from(window.crypto.getRandomValues(new Uint8Array(16)), byte =>
+    byte.toString(16).padStart(2, "0")
+  ).join("");
+}
+
 const CLEAR_CART = gql`
   mutation CheckoutClearCart {
     clearCart @client
@@ -153,6 +162,7 @@ function Profile() {
     setPaymentMethodRequestable
   ] = React.useState(false);
   const braintreeClient = React.useRef();
+  const [idempotencyKey] = React.useState(newIdempotencyKey);
 
   const {
     data: clientData,
@@ -511,7 +521,8 @@ function Profile() {
             shippingAddressId: shippingAddress.id,
             shippingAddress: shippingAddress.id ? null : shippingAddress,
             saveShippingAddress,
-            lineItems: queryVariables.variants
+            lineItems: queryVariables.variants,
+            idempotencyKey
           }
         });
 
@@ -536,7 +547,8 @@ function Profile() {
       checkoutMutation,
       setBraintreeError,
       setPaymentMethodRequestable,
-      clearCartMutation
+      clearCartMutation,
+      idempotencyKey
     ]
   );
 
@@ -27,6 +27,7 @@ const QUERY = gql`
         id
         labelUrl
       }
+      packingSlipUrl
       ...ReceiptTransaction
     }
   }
@@ -118,6 +119,15 @@ export default function Transaction() {
                         <strong>View Label</strong>
                       </a>
                     </p>
+                    <p>
+                      <a
+                        rel="noopener noreferrer"
+                        target="_blank"
+                        href={data.transaction.packingSlipUrl}
+                      >
+                        <strong>Print packing slip</strong>
+                      </a>
+                    </p>
                   </React.Fragment>
                 ) : (
                   <React.Fragment>
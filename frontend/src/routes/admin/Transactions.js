@@ -1,5 +1,10 @@
 import React from "react";
-import { useQueryParam, NumberParam } from "use-query-params";
+import {
+  useQueryParam,
+  useQueryParams,
+  NumberParam,
+  StringParam
+} from "use-query-params";
 import { Link } from "react-router-dom";
 import currency from "currency.js";
 
@@ -7,8 +12,10 @@ import { useQuery } from "@apollo/react-hooks";
 import { gql } from "apollo-boost";
 
 import Breadcrumb from "react-bootstrap/Breadcrumb";
+import Button from "react-bootstrap/Button";
 import Col from "react-bootstrap/Col";
 import Container from "react-bootstrap/Container";
+import Form from "react-bootstrap/Form";
 import Pagination from "react-bootstrap/Pagination";
 import Row from "react-bootstrap/Row";
 import Table from "react-bootstrap/Table";
@@ -16,9 +23,14 @@ import Table from "react-bootstrap/Table";
 import Error from "../../components/Error";
 
 const QUERY = gql`
-  query AdminTransactions($skip: Int, $limit: Int) {
-    transactions(skip: $skip, limit: $limit) {
+  query AdminTransactions(
+    $skip: Int
+    $limit: Int
+    $filter: TransactionFilterInput
+  ) {
+    transactions(skip: $skip, limit: $limit, filter: $filter) {
       id
+      status
       subtotal
       taxes
       shipping
@@ -27,6 +39,84 @@ const QUERY = gql`
   }
 `;
 
+const STATUSES = [
+  "RECEIVED",
+  "AUTHORIZED",
+  "SHIPPED",
+  "IN_TRANSIT",
+  "OUT_FOR_DELIVERY",
+  "DELIVERED",
+  "RETURNED",
+  "CANCELLED"
+];
+
+function toFilter({ email, status, trackingNumber, braintreeId }) {
+  const filter = {};
+  if (email) filter.email = email;
+  if (status) filter.status = status;
+  if (trackingNumber) filter.trackingNumber = trackingNumber;
+  if (braintreeId) filter.braintreeId = braintreeId;
+  return filter;
+}
+
+function SearchForm({ initialValues, onSearch }) {
+  const [values, setValues] = React.useState(initialValues);
+  const onChange = name => event =>
+    setValues({ ...values, [name]: event.target.value });
+
+  const handleSubmit = event => {
+    event.preventDefault();
+    onSearch(values);
+  };
+
+  return (
+    <Form onSubmit={handleSubmit}>
+      <Form.Row>
+        <Form.Group as={Col} md={3} controlId="searchEmail">
+          <Form.Label>Customer email</Form.Label>
+          <Form.Control
+            type="email"
+            value={values.email || ""}
+            onChange={onChange("email")}
+          />
+        </Form.Group>
+        <Form.Group as={Col} md={3} controlId="searchStatus">
+          <Form.Label>Status</Form.Label>
+          <Form.Control
+            as="select"
+            value={values.status || ""}
+            onChange={onChange("status")}
+          >
+            <option value="">Any</option>
+            {STATUSES.map(status => (
+              <option key={status} value={status}>
+                {status}
+              </option>
+            ))}
+          </Form.Control>
+        </Form.Group>
+        <Form.Group as={Col} md={3} controlId="searchTrackingNumber">
+          <Form.Label>Tracking number</Form.Label>
+          <Form.Control
+            value={values.trackingNumber || ""}
+            onChange={onChange("trackingNumber")}
+          />
+        </Form.Group>
+        <Form.Group as={Col} md={3} controlId="searchBraintreeId">
+          <Form.Label>Braintree ID</Form.Label>
+          <Form.Control
+            value={values.braintreeId || ""}
+            onChange={onChange("braintreeId")}
+          />
+        </Form.Group>
+      </Form.Row>
+      <Button variant="primary" type="submit">
+        Search
+      </Button>
+    </Form>
+  );
+}
+
 function toLink(item) {
   return `/admin/transactions/${item.id}`;
 }
@@ -41,9 +131,19 @@ export default function Transactions() {
     setSkip(next < 0 ? 0 : next, "pushIn");
   });
   const nextPage = React.useCallback(() => setSkip(skip + limit, "pushIn"));
+  const [search, setSearch] = useQueryParams({
+    email: StringParam,
+    status: StringParam,
+    trackingNumber: StringParam,
+    braintreeId: StringParam,
+    skip: NumberParam
+  });
+  const onSearch = React.useCallback(values =>
+    setSearch({ ...toFilter(values), skip: 0 }, "push")
+  );
 
   const { data, error, loading } = useQuery(QUERY, {
-    variables: { skip, limit }
+    variables: { skip, limit, filter: toFilter(search) }
   });
 
   return (
@@ -57,11 +157,17 @@ export default function Transactions() {
           <Error error={error} />
         </Col>
       </Row>
+      <Row>
+        <Col className="mb-4" xs={12}>
+          <SearchForm initialValues={search} onSearch={onSearch} />
+        </Col>
+      </Row>
       <Row>
         <Table responsive>
           <thead>
             <tr>
               <th>#</th>
+              <th>Status</th>
               <th>Subtotal</th>
               <th>Taxes</th>
               <th>Shipping</th>
@@ -77,6 +183,9 @@ export default function Transactions() {
                   <td>
                     <Link to={toLink(transaction)}>{transaction.id}</Link>
                   </td>
+                  <td>
+                    <Link to={toLink(transaction)}>{transaction.status}</Link>
+                  </td>
                   <td>
                     <Link to={toLink(transaction)}>
                       ${currency(transaction.subtotal / 100).format()}
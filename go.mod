@@ -3,6 +3,7 @@ module github.com/jacob-ebey/golang-ecomm
 go 1.13
 
 require (
+	github.com/boombuler/barcode v1.0.1 // indirect
 	github.com/braintree-go/braintree-go v0.22.0
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/disintegration/imaging v1.6.2
@@ -15,6 +16,7 @@ require (
 	github.com/jacob-ebey/graphql-httphandler v0.0.0-20191125001422-37ef33d43fc7
 	github.com/jacob-ebey/now-storage-go v0.0.0-20191031010212-59f0f487d837
 	github.com/joho/godotenv v1.3.0
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/onsi/ginkgo v1.10.3 // indirect
 	github.com/onsi/gomega v1.7.1 // indirect
 	github.com/opentracing/opentracing-go v1.1.0 // indirect
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"path"
 	"time"
@@ -12,6 +14,8 @@ import (
 
 	"github.com/jacob-ebey/golang-ecomm/auth"
 	"github.com/jacob-ebey/golang-ecomm/runtime"
+	"github.com/jacob-ebey/golang-ecomm/schema"
+	"github.com/jacob-ebey/golang-ecomm/services"
 )
 
 func main() {
@@ -31,6 +35,26 @@ func main() {
 		panic(err)
 	}
 
+	if delay := runtime.AbandonedCartReminderDelay(); delay > 0 {
+		runtime.StartJob(executor, 10*time.Minute, func(ctx context.Context) error {
+			sent, err := schema.SendAbandonedCartReminders(ctx, delay)
+			if sent > 0 {
+				fmt.Printf("Sent %d abandoned cart reminders.\n", sent)
+			}
+			return err
+		})
+	}
+
+	if provider := runtime.ShippingProvider(); provider == "table" || provider == "" {
+		runtime.StartJob(executor, time.Hour, func(ctx context.Context) error {
+			deleted, err := services.DeleteExpiredShippingRateQuotes(ctx)
+			if deleted > 0 {
+				fmt.Printf("Deleted %d expired shipping rate quotes.\n", deleted)
+			}
+			return err
+		})
+	}
+
 	handler := httphandler.GraphQLHttpHandler{
 		Executor:   *executor,
 		Playground: true,
@@ -38,6 +62,10 @@ func main() {
 
 	router := mux.NewRouter()
 	router.HandleFunc("/graphql", handler.ServeHTTP)
+	router.HandleFunc("/webhooks/payments", runtime.NewContextHandler(executor, schema.HandlePaymentWebhook))
+	router.HandleFunc("/webhooks/shipping", runtime.NewContextHandler(executor, schema.HandleTrackingWebhook))
+	router.HandleFunc("/exports/transactions", runtime.NewContextHandler(executor, schema.HandleTransactionExport))
+	router.HandleFunc("/documents/{document}", runtime.NewContextHandler(executor, schema.HandleDocument))
 
 	if runtime.ShouldServeStaticFiles() {
 		fileServer := http.FileServer(http.Dir(path.Clean("./frontend/build")))
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/joho/godotenv"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/runtime"
+)
+
+const usage = `Usage: migrate <command>
+
+Commands:
+  up           Apply the pending migrations.
+  down [steps] Roll back the last applied migrations. Defaults to one.
+  status       List the migrations and when they were applied.`
+
+func main() {
+	godotenv.Load(".env")
+
+	if len(os.Args) < 2 {
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	database := pg.Connect(runtime.GetPgOptions())
+	defer database.Close()
+
+	var err error
+	switch os.Args[1] {
+	case "up":
+		err = up(database)
+	case "down":
+		steps := 1
+		if len(os.Args) > 2 {
+			steps, err = strconv.Atoi(os.Args[2])
+			if err != nil || steps < 1 {
+				fmt.Println("The number of steps must be a positive number.")
+				os.Exit(2)
+			}
+		}
+		err = down(database, steps)
+	case "status":
+		err = status(database)
+	default:
+		fmt.Println(usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		runtime.PrintError(err)
+		os.Exit(1)
+	}
+}
+
+func up(database *pg.DB) error {
+	applied, err := db.Migrate(database, db.Migrations)
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		fmt.Println("The database is up to date.")
+	}
+	for _, migration := range applied {
+		fmt.Printf("Applied migration %d: %s\n", migration.Version, migration.Description)
+	}
+
+	return nil
+}
+
+func down(database *pg.DB, steps int) error {
+	rolledBack, err := db.Rollback(database, db.Migrations, steps)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range rolledBack {
+		fmt.Printf("Rolled back migration %d: %s\n", migration.Version, migration.Description)
+	}
+
+	return nil
+}
+
+func status(database *pg.DB) error {
+	statuses, err := db.GetMigrationStatus(database, db.Migrations)
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+
+		fmt.Printf("%4d  %-25s  %s\n", status.Migration.Version, appliedAt, status.Migration.Description)
+	}
+
+	return nil
+}
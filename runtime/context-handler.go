@@ -0,0 +1,28 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+
+	core "github.com/jacob-ebey/graphql-core"
+)
+
+type ContextHandlerFunc func(ctx context.Context, w http.ResponseWriter, r *http.Request)
+
+// Serves requests outside of GraphQL, such as webhooks, with the same context the resolvers get.
+func NewContextHandler(executor *core.GraphQLExecutor, handle ContextHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := core.GraphQLRequest{}
+
+		ctx := context.WithValue(r.Context(), "request", r)
+		for _, hook := range executor.RunBefore {
+			ctx = hook.PreExecute(ctx, req)
+		}
+
+		handle(ctx, w, r)
+
+		for _, hook := range executor.RunAfter {
+			hook.PostExecute(ctx, req, nil)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-pg/pg/v9"
 
@@ -24,6 +25,62 @@ func IsDevelopment() bool { return os.Getenv("ENVIRONMENT") == "development" }
 
 func ShippoPrivateToken() string { return os.Getenv("SHIPPO_PRIVATE_TOKEN") }
 
+func ShippoWebhookToken() string { return os.Getenv("SHIPPO_WEBHOOK_TOKEN") }
+
+func SendDeliveryEmails() bool { return os.Getenv("SEND_DELIVERY_EMAILS") == "true" }
+
+// Either "shippo" or "table". Defaults to shippo when a shippo token is provided.
+func ShippingProvider() string {
+	provider := os.Getenv("SHIPPING_PROVIDER")
+	if provider == "" && ShippoPrivateToken() != "" {
+		return "shippo"
+	}
+
+	return provider
+}
+
+// Either "avatax" or "local". Defaults to avatax when avatax credentials are provided.
+func TaxProvider() string {
+	provider := os.Getenv("TAX_PROVIDER")
+	if provider == "" && GetAvatax().HasAuthorization() {
+		return "avatax"
+	}
+
+	return provider
+}
+
+// Either "braintree" or "fake". Defaults to fake in development when no braintree credentials are
+// provided, and to braintree otherwise.
+func PaymentGateway() string {
+	provider := os.Getenv("PAYMENT_GATEWAY")
+	if provider == "" {
+		if IsDevelopment() && Braintree().MerchantID == "" {
+			return "fake"
+		}
+
+		return "braintree"
+	}
+
+	return provider
+}
+
+// The secret the fake payment gateway signs and verifies webhooks with.
+func FakePaymentsWebhookSecret() string { return os.Getenv("FAKE_PAYMENTS_WEBHOOK_SECRET") }
+
+// Only authorize payments at checkout. They are captured when a label is purchased or an admin
+// captures them.
+func AuthorizeOnly() bool { return os.Getenv("AUTHORIZE_ONLY") == "true" }
+
+// Whether to apply pending migrations when the server starts. Defaults to true.
+func MigrateOnStart() bool { return os.Getenv("MIGRATE_ON_START") != "false" }
+
+// How long a signed in customer's cart has to go unchanged before they are emailed a reminder. Reminders
+// are not sent when this is not set.
+func AbandonedCartReminderDelay() time.Duration {
+	minutes, _ := strconv.Atoi(os.Getenv("ABANDONED_CART_REMINDER_MINUTES"))
+	return time.Duration(minutes) * time.Minute
+}
+
 func ShouldServeStaticFiles() bool { return os.Getenv("GO_SERVES_STATIC") == "true" }
 
 func Braintree() BraintreeConfig {
@@ -105,11 +162,17 @@ func GetPgOptions() *pg.Options {
 }
 
 func GetAvatax() *apis.Avatax {
+	companyCode := os.Getenv("AVATAX_COMPANY_CODE")
+	if companyCode == "" {
+		companyCode = "DEFAULT"
+	}
+
 	return &apis.Avatax{
 		BearerToken: os.Getenv("AVATAX_BEARER_TOKEN"),
 		Username:    os.Getenv("AVATAX_USERNAME"),
 		Password:    os.Getenv("AVATAX_PASSWORD"),
 		AccountID:   os.Getenv("AVATAX_ACCOUNTID"),
 		LicenseKey:  os.Getenv("AVATAX_LICENSEKEY"),
+		CompanyCode: companyCode,
 	}
 }
@@ -1,6 +1,7 @@
 package runtime
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/braintree-go/braintree-go"
@@ -43,7 +44,7 @@ func NewExecutor(opts NewExecutorOpts) (*core.GraphQLExecutor, error) {
 		JwtSecret: JwtSecret(),
 	}
 
-	databaseHook, err := db.NewDatabaseHook(GetPgOptions())
+	databaseHook, err := db.NewDatabaseHook(GetPgOptions(), MigrateOnStart())
 	if err != nil {
 		return nil, &core.WrappedError{
 			Message:       "Failed to create database hook",
@@ -51,18 +52,56 @@ func NewExecutor(opts NewExecutorOpts) (*core.GraphQLExecutor, error) {
 		}
 	}
 
-	avataxHook := NewProviderHook("avatax", GetAvatax())
-
-	shippoHook := NewProviderHook("shippo", shippo.NewClient(ShippoPrivateToken()))
-
-	braintreeConfig := Braintree()
-	braintreeEnvironment := braintree.Production
-	if IsDevelopment() {
-		braintreeEnvironment = braintree.Sandbox
+	var taxProvider services.TaxProvider
+	switch TaxProvider() {
+	case "avatax":
+		taxProvider = &services.AvataxTaxProvider{
+			Avatax: GetAvatax(),
+		}
+	case "local", "":
+		taxProvider = &services.LocalTaxProvider{}
+	default:
+		return nil, fmt.Errorf("Unknown tax provider \"%s\".", TaxProvider())
+	}
+	taxProviderHook := NewProviderHook("taxProvider", taxProvider)
+
+	var shippingProvider services.ShippingProvider
+	switch ShippingProvider() {
+	case "shippo":
+		shippingProvider = &services.ShippoShippingProvider{
+			Client:       shippo.NewClient(ShippoPrivateToken()),
+			WebhookToken: ShippoWebhookToken(),
+		}
+	case "table", "":
+		shippingProvider = &services.TableRateShippingProvider{}
+	default:
+		return nil, fmt.Errorf("Unknown shipping provider \"%s\".", ShippingProvider())
 	}
-	braintreeHook := NewProviderHook(
-		"braintree",
-		braintree.New(braintreeEnvironment, braintreeConfig.MerchantID, braintreeConfig.PublicKey, braintreeConfig.PrivateKey))
+	shippingProviderHook := NewProviderHook("shippingProvider", shippingProvider)
+
+	var paymentGateway services.PaymentGateway
+	switch PaymentGateway() {
+	case "braintree":
+		braintreeConfig := Braintree()
+		braintreeEnvironment := braintree.Production
+		if IsDevelopment() {
+			braintreeEnvironment = braintree.Sandbox
+		}
+		paymentGateway = &services.BraintreePaymentGateway{
+			Client: braintree.New(braintreeEnvironment, braintreeConfig.MerchantID, braintreeConfig.PublicKey, braintreeConfig.PrivateKey),
+		}
+	case "fake":
+		paymentGateway = &services.FakePaymentGateway{
+			WebhookSecret: FakePaymentsWebhookSecret(),
+		}
+	default:
+		return nil, fmt.Errorf("Unknown payment gateway \"%s\".", PaymentGateway())
+	}
+	paymentGatewayHook := NewProviderHook("paymentGateway", paymentGateway)
+	authorizeOnlyHook := NewProviderHook("authorizeOnly", AuthorizeOnly())
+
+	sendDeliveryEmailsHook := NewProviderHook("sendDeliveryEmails", SendDeliveryEmails())
+	abandonedCartReminderDelayHook := NewProviderHook("abandonedCartReminderDelay", AbandonedCartReminderDelay())
 
 	smtpConfig := Smtp()
 	smtpPort := strconv.Itoa(smtpConfig.Port)
@@ -80,12 +119,15 @@ func NewExecutor(opts NewExecutorOpts) (*core.GraphQLExecutor, error) {
 			authHook,
 			databaseHook,
 			dataloaders.HooksDataloader,
-			avataxHook,
-			shippoHook,
-			services.ValidateAddressWithShippo,
+			taxProviderHook,
+			shippingProviderHook,
+			services.ValidateAddressWithShippingProvider,
 			services.ResizeImage,
-			braintreeHook,
+			paymentGatewayHook,
+			authorizeOnlyHook,
 			emailHook,
+			sendDeliveryEmailsHook,
+			abandonedCartReminderDelayHook,
 			nowStorageHook,
 		),
 		RunAfter: append(opts.RunAfter,
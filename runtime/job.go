@@ -0,0 +1,40 @@
+package runtime
+
+import (
+	"context"
+	"time"
+
+	core "github.com/jacob-ebey/graphql-core"
+)
+
+type JobFunc func(ctx context.Context) error
+
+// Runs a job in the background every interval, with the same context the resolvers get, for as long
+// as the process runs. Errors are printed and the job runs again on the next interval.
+func StartJob(executor *core.GraphQLExecutor, interval time.Duration, job JobFunc) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runJob(executor, job)
+		}
+	}()
+}
+
+func runJob(executor *core.GraphQLExecutor, job JobFunc) {
+	req := core.GraphQLRequest{}
+
+	ctx := context.Background()
+	for _, hook := range executor.RunBefore {
+		ctx = hook.PreExecute(ctx, req)
+	}
+
+	if err := job(ctx); err != nil {
+		PrintError(err)
+	}
+
+	for _, hook := range executor.RunAfter {
+		hook.PostExecute(ctx, req, nil)
+	}
+}
@@ -0,0 +1,177 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/email"
+)
+
+// Carts left longer than this are not reminded of, so turning reminders on does not email every
+// customer that ever left something in their cart.
+const abandonedCartMaxAge = 7 * 24 * time.Hour
+
+var AbandonedCartRemindersDisabledError = fmt.Errorf("Abandoned cart reminders are not enabled. Set ABANDONED_CART_REMINDER_MINUTES or provide idleMinutes.")
+
+// Emails the signed in customers whose cart has not changed for the idle duration and who have not
+// checked out since, once per change to their cart. Carts are changed only through the cart
+// mutations, which keep their updated_at current. Returns the number of reminders sent.
+func SendAbandonedCartReminders(ctx context.Context, idle time.Duration) (int, error) {
+	database := ctx.Value("database").(*pg.DB)
+	emailClient := ctx.Value("email").(email.Client)
+	baseUrl := ctx.Value("baseUrl").(string)
+
+	// Postgres keeps microseconds, so the time the reminder is claimed at can be matched again.
+	now := time.Now().Truncate(time.Microsecond)
+
+	carts := []*db.Cart{}
+	if err := database.
+		Model(&carts).
+		Relation("User").
+		Relation("Items", func(query *orm.Query) (*orm.Query, error) {
+			return query.OrderExpr("cart_item.id ASC"), nil
+		}).
+		Where("cart.user_id IS NOT NULL").
+		Where("cart.updated_at < ?", now.Add(-idle)).
+		Where("cart.updated_at > ?", now.Add(-abandonedCartMaxAge)).
+		Where("cart.reminder_sent_at IS NULL OR cart.reminder_sent_at < cart.updated_at").
+		Where("EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = cart.id)").
+		Where("NOT EXISTS (SELECT 1 FROM transactions WHERE transactions.user_id = cart.user_id AND transactions.created_at >= cart.updated_at)").
+		OrderExpr("cart.id ASC").
+		Select(); err != nil {
+		return 0, &core.WrappedError{
+			Message:       "Could not get abandoned carts.",
+			InternalError: err,
+		}
+	}
+
+	sent := 0
+	for _, cart := range carts {
+		toSend, err := newAbandonedCartEmail(ctx, baseUrl, cart)
+		if err != nil {
+			fmt.Println("Failed to create abandoned cart email.")
+			fmt.Println(err)
+			continue
+		}
+
+		// Claim the reminder first so another server sending reminders at the same time skips it.
+		result, err := database.
+			Model((*db.Cart)(nil)).
+			Set("reminder_sent_at = ?", now).
+			Where("cart.id = ?", cart.ID).
+			Where("cart.updated_at = ?", cart.UpdatedAt).
+			Where("cart.reminder_sent_at IS NULL OR cart.reminder_sent_at < cart.updated_at").
+			Update()
+		if err != nil {
+			fmt.Println("Failed to record abandoned cart reminder.")
+			fmt.Println(err)
+			continue
+		}
+		if result.RowsAffected() == 0 {
+			continue
+		}
+
+		if err := emailClient.SendMail(cart.User.Email, "You left something in your cart.", toSend); err != nil {
+			fmt.Println("Failed to send abandoned cart email.")
+			fmt.Println(err)
+
+			// Leave the cart to be reminded of on the next run.
+			if _, err := database.
+				Model((*db.Cart)(nil)).
+				Set("reminder_sent_at = ?", pg.NullTime{Time: cart.ReminderSentAt}).
+				Where("cart.id = ?", cart.ID).
+				Where("cart.reminder_sent_at = ?", now).
+				Update(); err != nil {
+				fmt.Println("Failed to reset abandoned cart reminder.")
+				fmt.Println(err)
+			}
+			continue
+		}
+
+		sent++
+	}
+
+	return sent, nil
+}
+
+func newAbandonedCartEmail(ctx context.Context, baseUrl string, cart *db.Cart) (string, error) {
+	productLoader := ctx.Value("product").(*dataloader.Loader)
+	productVariantLoader := ctx.Value("productVariant").(*dataloader.Loader)
+
+	variantIDs := make(dataloader.Keys, len(cart.Items))
+	for index, item := range cart.Items {
+		variantIDs[index] = dataloaders.IntKey(item.ProductVariantID)
+	}
+	variantsTemp, errs := productVariantLoader.LoadMany(ctx, variantIDs)()
+	if errs != nil && len(errs) > 0 {
+		return "", dataloaders.HandleErrors(errs)
+	}
+
+	items := make([]email.AbandonedCartItem, len(cart.Items))
+	for index, item := range cart.Items {
+		variant := variantsTemp[index].(*db.ProductVariant)
+		name := variant.Name
+
+		if name == "" {
+			product, err := productLoader.Load(ctx, dataloaders.IntKey(variant.ProductID))()
+			if err != nil || product == nil {
+				return "", &core.WrappedError{
+					Message:       "Could not get product variant name.",
+					InternalError: err,
+				}
+			}
+
+			name = product.(*db.Product).Name
+		}
+
+		items[index] = email.AbandonedCartItem{
+			Name:     name,
+			Quantity: item.Quantity,
+			Price:    variant.Price,
+		}
+	}
+
+	return email.NewAbandonedCartEmail(baseUrl, items)
+}
+
+var SendAbandonedCartRemindersField = &graphql.Field{
+	Type:        graphql.NewNonNull(graphql.Int),
+	Description: "Email the customers that left items in their cart without checking out. Returns the number of reminders sent. The server sends these on its own when ABANDONED_CART_REMINDER_MINUTES is set; this is for deployments that cannot run background jobs.",
+	Args: graphql.FieldConfigArgument{
+		"idleMinutes": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "How long a cart has to be unchanged. Defaults to ABANDONED_CART_REMINDER_MINUTES.",
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		reminderDelay := params.Context.Value("abandonedCartReminderDelay").(time.Duration)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		idle := reminderDelay
+		if idleMinutes, ok := params.Args["idleMinutes"].(int); ok {
+			idle = time.Duration(idleMinutes) * time.Minute
+		}
+		if idle <= 0 {
+			return nil, AbandonedCartRemindersDisabledError
+		}
+
+		return SendAbandonedCartReminders(params.Context, idle)
+	},
+}
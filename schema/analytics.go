@@ -0,0 +1,400 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+var InvalidDateRangeError = fmt.Errorf("The start of the date range must be before its end.")
+
+type salesPeriod struct {
+	Start             time.Time
+	Orders            int
+	Revenue           int
+	AverageOrderValue int
+	Taxes             int
+	Shipping          int
+}
+
+type salesTotals struct {
+	Orders            int
+	Revenue           int
+	AverageOrderValue int
+	Subtotal          int
+	Taxes             int
+	ShippingTaxes     int
+	Shipping          int
+	Refunded          int
+}
+
+type topSeller struct {
+	ProductID        int
+	ProductVariantID int
+	Units            int
+	Revenue          int
+}
+
+var SalesIntervalEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "SalesInterval",
+	Values: graphql.EnumValueConfigMap{
+		"DAY": &graphql.EnumValueConfig{
+			Value: "day",
+		},
+		"WEEK": &graphql.EnumValueConfig{
+			Value: "week",
+		},
+		"MONTH": &graphql.EnumValueConfig{
+			Value: "month",
+		},
+	},
+})
+
+var TopSellerSortEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "TopSellerSort",
+	Values: graphql.EnumValueConfigMap{
+		"UNITS": &graphql.EnumValueConfig{
+			Value: "units DESC, revenue DESC",
+		},
+		"REVENUE": &graphql.EnumValueConfig{
+			Value: "revenue DESC, units DESC",
+		},
+	},
+})
+
+var SalesPeriodType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "SalesPeriod",
+	Description: "The sales of a day, week or month. Amounts are in cents.",
+	Fields: graphql.Fields{
+		"start": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+		},
+		"orders": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"revenue": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"averageOrderValue": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"taxes": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shipping": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+})
+
+var SalesTotalsType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "SalesTotals",
+	Description: "The sales of a date range. Amounts are in cents.",
+	Fields: graphql.Fields{
+		"orders": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"revenue": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"averageOrderValue": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"subtotal": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"taxes": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "The taxes collected, including the taxes on shipping.",
+		},
+		"shippingTaxes": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shipping": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"refunded": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "The amount refunded in the date range, whenever the orders were placed.",
+		},
+	},
+})
+
+var TopProductType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TopProduct",
+	Fields: graphql.Fields{
+		"units": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"revenue": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"product": &graphql.Field{
+			Type: ProductType,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				product := params.Context.Value("product").(*dataloader.Loader)
+
+				seller := params.Source.(*topSeller)
+
+				thunk := product.Load(params.Context, dataloaders.IntKey(seller.ProductID))
+
+				return func() (interface{}, error) {
+					return thunk()
+				}, nil
+			},
+		},
+	},
+})
+
+var TopProductVariantType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TopProductVariant",
+	Fields: graphql.Fields{
+		"units": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"revenue": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"variant": &graphql.Field{
+			Type: ProductVariantType,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				productVariant := params.Context.Value("productVariant").(*dataloader.Loader)
+
+				seller := params.Source.(*topSeller)
+
+				thunk := productVariant.Load(params.Context, dataloaders.IntKey(seller.ProductVariantID))
+
+				return func() (interface{}, error) {
+					return thunk()
+				}, nil
+			},
+		},
+	},
+})
+
+var dateRangeArgs = graphql.FieldConfigArgument{
+	"from": &graphql.ArgumentConfig{
+		Type: graphql.NewNonNull(graphql.DateTime),
+	},
+	"to": &graphql.ArgumentConfig{
+		Type:        graphql.NewNonNull(graphql.DateTime),
+		Description: "The end of the range, excluded.",
+	},
+}
+
+// Checks the user is an admin and parses the date range of an analytics query.
+func analyticsDateRange(params graphql.ResolveParams) (time.Time, time.Time, error) {
+	claims := params.Context.Value("claims").(*auth.Claims)
+	if claims == nil {
+		return time.Time{}, time.Time{}, auth.NotAuthenticatedError
+	}
+	if claims.Role != "ADMIN" {
+		return time.Time{}, time.Time{}, auth.NotAuthorizedError
+	}
+
+	from, _ := params.Args["from"].(time.Time)
+	to, _ := params.Args["to"].(time.Time)
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, InvalidDateRangeError
+	}
+
+	return from, to, nil
+}
+
+// Limits a query of transactions to the orders placed in the date range that were not cancelled.
+func whereSold(query *orm.Query, from time.Time, to time.Time) *orm.Query {
+	return query.
+		Where("transaction.created_at >= ?", from).
+		Where("transaction.created_at < ?", to).
+		Where(`NOT EXISTS (SELECT 1 FROM transaction_statuses AS cancelled
+			WHERE cancelled.transaction_id = transaction.id AND cancelled.status = ?)`, TransactionStatusCancelled)
+}
+
+// The args of the top seller queries.
+func topSellerArgs() graphql.FieldConfigArgument {
+	args := graphql.FieldConfigArgument{
+		"sort": &graphql.ArgumentConfig{
+			Type:         TopSellerSortEnum,
+			DefaultValue: "units DESC, revenue DESC",
+		},
+		"limit": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: 10,
+		},
+	}
+	for name, arg := range dateRangeArgs {
+		args[name] = arg
+	}
+
+	return args
+}
+
+// Sums the line items of the orders placed in the date range, grouped by the column.
+func topSellers(params graphql.ResolveParams, group string) ([]*topSeller, error) {
+	database := params.Context.Value("database").(*pg.DB)
+
+	from, to, err := analyticsDateRange(params)
+	if err != nil {
+		return nil, err
+	}
+
+	sort, _ := params.Args["sort"].(string)
+	if sort == "" {
+		sort = "units DESC, revenue DESC"
+	}
+	limit, _ := params.Args["limit"].(int)
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	results := []*topSeller{}
+	query := database.
+		Model((*db.Transaction)(nil)).
+		ColumnExpr(group).
+		ColumnExpr("sum(line_item.quantity) AS units").
+		ColumnExpr("sum(line_item.price * line_item.quantity) AS revenue").
+		Join("JOIN transaction_line_items AS line_item ON line_item.transaction_id = transaction.id").
+		Join("JOIN product_variants AS variant ON variant.id = line_item.product_variant_id")
+	if err := whereSold(query, from, to).
+		GroupExpr(group).
+		OrderExpr(sort).
+		Limit(limit).
+		Select(&results); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Failed to calculate the top sellers.",
+			InternalError: err,
+		}
+	}
+
+	return results, nil
+}
+
+var SalesReportField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(SalesPeriodType)),
+	Description: "The sales of every day, week or month in the date range. Cancelled orders are left out.",
+	Args: graphql.FieldConfigArgument{
+		"from": dateRangeArgs["from"],
+		"to":   dateRangeArgs["to"],
+		"interval": &graphql.ArgumentConfig{
+			Type:         SalesIntervalEnum,
+			DefaultValue: "day",
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		from, to, err := analyticsDateRange(params)
+		if err != nil {
+			return nil, err
+		}
+
+		interval, _ := params.Args["interval"].(string)
+		if interval == "" {
+			interval = "day"
+		}
+
+		// Every period of the range is returned, including the ones without orders.
+		results := []*salesPeriod{}
+		if _, err := database.Query(&results, `
+			SELECT
+				period.start,
+				count(sold.id) AS orders,
+				coalesce(sum(sold.total), 0) AS revenue,
+				coalesce(round(avg(sold.total)), 0) AS average_order_value,
+				coalesce(sum(sold.taxes), 0) AS taxes,
+				coalesce(sum(sold.shipping), 0) AS shipping
+			FROM generate_series(
+				date_trunc(?0, ?1::timestamptz),
+				?2::timestamptz - interval '1 microsecond',
+				('1 ' || ?0)::interval
+			) AS period(start)
+			LEFT JOIN transactions AS sold
+				ON date_trunc(?0, sold.created_at) = period.start
+				AND sold.created_at >= ?1
+				AND sold.created_at < ?2
+				AND NOT EXISTS (SELECT 1 FROM transaction_statuses AS cancelled
+					WHERE cancelled.transaction_id = sold.id AND cancelled.status = ?3)
+			GROUP BY period.start
+			ORDER BY period.start ASC
+		`, interval, from, to, TransactionStatusCancelled); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Failed to calculate the sales report.",
+				InternalError: err,
+			}
+		}
+
+		return results, nil
+	},
+}
+
+var SalesTotalsField = &graphql.Field{
+	Type:        graphql.NewNonNull(SalesTotalsType),
+	Description: "The sales of the date range. Cancelled orders are left out.",
+	Args:        dateRangeArgs,
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		from, to, err := analyticsDateRange(params)
+		if err != nil {
+			return nil, err
+		}
+
+		totals := salesTotals{}
+		query := database.
+			Model((*db.Transaction)(nil)).
+			ColumnExpr("count(*) AS orders").
+			ColumnExpr("coalesce(sum(transaction.total), 0) AS revenue").
+			ColumnExpr("coalesce(round(avg(transaction.total)), 0) AS average_order_value").
+			ColumnExpr("coalesce(sum(transaction.subtotal), 0) AS subtotal").
+			ColumnExpr("coalesce(sum(transaction.taxes), 0) AS taxes").
+			ColumnExpr("coalesce(sum(transaction.shipping_tax), 0) AS shipping_taxes").
+			ColumnExpr("coalesce(sum(transaction.shipping), 0) AS shipping")
+		if err := whereSold(query, from, to).Select(&totals); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Failed to calculate the sales totals.",
+				InternalError: err,
+			}
+		}
+
+		if err := database.
+			Model((*db.TransactionRefund)(nil)).
+			ColumnExpr("coalesce(sum(transaction_refund.amount), 0)").
+			Where("transaction_refund.created_at >= ?", from).
+			Where("transaction_refund.created_at < ?", to).
+			Select(pg.Scan(&totals.Refunded)); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Failed to calculate the refunded total.",
+				InternalError: err,
+			}
+		}
+
+		return &totals, nil
+	},
+}
+
+var TopProductsField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(TopProductType)),
+	Description: "The products that sold the most units or made the most revenue in the date range.",
+	Args:        topSellerArgs(),
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		return topSellers(params, "variant.product_id")
+	},
+}
+
+var TopProductVariantsField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(TopProductVariantType)),
+	Description: "The product variants that sold the most units or made the most revenue in the date range.",
+	Args:        topSellerArgs(),
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		return topSellers(params, "line_item.product_variant_id")
+	},
+}
@@ -54,6 +54,10 @@ var SignUpField = &graphql.Field{
 		"confirmPassword": &graphql.ArgumentConfig{
 			Type: graphql.NewNonNull(graphql.String),
 		},
+		"cartToken": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The token of the guest cart to move into the account.",
+		},
 	},
 	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
 		database := params.Context.Value("database").(*pg.DB)
@@ -95,6 +99,13 @@ var SignUpField = &graphql.Field{
 			}
 		}
 
+		if cartToken, ok := params.Args["cartToken"].(string); ok && cartToken != "" {
+			if err := mergeGuestCart(database, user.ID, cartToken); err != nil {
+				fmt.Println("Failed to merge guest cart.")
+				fmt.Println(err)
+			}
+		}
+
 		token, err := tokenGenerator.GenerateToken(params.Context, auth.Claims{
 			ID:    user.ID,
 			Email: user.Email,
@@ -134,6 +145,10 @@ var SignInField = &graphql.Field{
 		"password": &graphql.ArgumentConfig{
 			Type: graphql.NewNonNull(graphql.String),
 		},
+		"cartToken": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The token of the guest cart to move into the account.",
+		},
 	},
 	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
 		database := params.Context.Value("database").(*pg.DB)
@@ -154,6 +169,13 @@ var SignInField = &graphql.Field{
 			return nil, LoginError
 		}
 
+		if cartToken, ok := params.Args["cartToken"].(string); ok && cartToken != "" {
+			if err := mergeGuestCart(database, user.ID, cartToken); err != nil {
+				fmt.Println("Failed to merge guest cart.")
+				fmt.Println(err)
+			}
+		}
+
 		token, err := tokenGenerator.GenerateToken(params.Context, auth.Claims{
 			ID:    user.ID,
 			Email: user.Email,
@@ -4,15 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"math"
 	"strconv"
 	"time"
 
-	"github.com/braintree-go/braintree-go"
 	"github.com/go-pg/pg/v9"
 	"github.com/graph-gophers/dataloader"
 	"github.com/graphql-go/graphql"
-	"github.com/jacob-ebey/go-shippo/client"
 	"github.com/jacob-ebey/golang-ecomm/auth"
 	"github.com/jacob-ebey/golang-ecomm/dataloaders"
 	"github.com/jacob-ebey/golang-ecomm/db"
@@ -21,67 +18,52 @@ import (
 	core "github.com/jacob-ebey/graphql-core"
 )
 
+var ShippingRateCountError = fmt.Errorf("A shipping rate must be selected for each origin the order ships from.")
+
 var BraintreeClientTokenField = &graphql.Field{
 	Type:        graphql.NewNonNull(graphql.String),
-	Description: "Get a braintree client token.",
+	Description: "Get a client token for the payment gateway.",
 	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
-		braintreeClient := params.Context.Value("braintree").(*braintree.Braintree)
-
-		token, err := braintreeClient.ClientToken().Generate(params.Context)
+		paymentGateway := params.Context.Value("paymentGateway").(services.PaymentGateway)
 
-		if err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not create braintree client token.",
-				InternalError: err,
-			}
-		}
-
-		return token, nil
+		return paymentGateway.ClientToken(params.Context)
 	},
 }
 
 var SubmitBraintreeTransactionField = &graphql.Field{
-	Type: ReceiptType,
-	Args: graphql.FieldConfigArgument{
-		"braintreeNonce": &graphql.ArgumentConfig{
-			Type: graphql.NewNonNull(graphql.String),
-		},
-		"billingAddressId": &graphql.ArgumentConfig{
-			Type: graphql.Int,
-		},
-		"billingAddress": &graphql.ArgumentConfig{
-			Type: AddressInputSchema,
-		},
-		"saveBillingAddress": &graphql.ArgumentConfig{
-			Type: graphql.Boolean,
-		},
-		"shippingAddressId": &graphql.ArgumentConfig{
-			Type: graphql.Int,
-		},
-		"shippingAddress": &graphql.ArgumentConfig{
-			Type: AddressInputSchema,
-		},
-		"saveShippingAddress": &graphql.ArgumentConfig{
-			Type: graphql.Boolean,
-		},
-		"total": &graphql.ArgumentConfig{
-			Type: graphql.NewNonNull(graphql.Int),
-		},
-		"shippingRateId": &graphql.ArgumentConfig{
-			Type: graphql.NewNonNull(graphql.String),
-		},
-		"variants": &graphql.ArgumentConfig{
-			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(CartInputSchema))),
-		},
-	},
+	Type:        ReceiptType,
+	Description: "Place an order and charge for it. Pass a quote from createCheckoutQuote to be charged what it says, or the variants and the expected total to price the order now.",
+	Args: func() graphql.FieldConfigArgument {
+		args := checkoutArgs()
+		args["braintreeNonce"] = &graphql.ArgumentConfig{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "The payment method nonce from the payment gateway.",
+		}
+		args["quoteId"] = &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The checkout quote to place the order with. The other checkout arguments are ignored when it is provided.",
+		}
+		args["total"] = &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "The total the customer was shown, required without a quote.",
+		}
+		args["variants"] = &graphql.ArgumentConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(CartInputSchema)),
+			Description: "The cart, required without a quote.",
+		}
+		args["idempotencyKey"] = &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "A unique key for the checkout, such as a UUID. Retrying with the same key returns the original receipt instead of charging again.",
+		}
+		return args
+	}(),
 	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
 		database := params.Context.Value("database").(*pg.DB)
-		shippoClient := params.Context.Value("shippo").(*client.Client)
-		subtotalLoader := params.Context.Value("subtotal").(*dataloader.Loader)
-		taxesLoader := params.Context.Value("taxes").(*dataloader.Loader)
+		addressLoader := params.Context.Value("address").(*dataloader.Loader)
 		productLoader := params.Context.Value("product").(*dataloader.Loader)
 		productVariantLoader := params.Context.Value("productVariant").(*dataloader.Loader)
-		braintreeClient := params.Context.Value("braintree").(*braintree.Braintree)
+		paymentGateway := params.Context.Value("paymentGateway").(services.PaymentGateway)
+		authorizeOnly := params.Context.Value("authorizeOnly").(bool)
 		emailClient := params.Context.Value("email").(email.Client)
 		baseUrl := params.Context.Value("baseUrl").(string)
 
@@ -91,267 +73,258 @@ var SubmitBraintreeTransactionField = &graphql.Field{
 			userID = claims.ID
 		}
 
-		braintreeNonce := params.Args["braintreeNonce"].(string)
-
-		billingAddressID, _ := params.Args["billingAddressId"].(int)
-		var billingAddress *db.Address = nil
-		if billingAddressTemp, ok := params.Args["billingAddress"]; ok {
-			billingAddress = &db.Address{}
-			if err := ConvertObject(billingAddressTemp, billingAddress); err != nil {
-				return nil, &core.WrappedError{
-					Message:       "Could not decode billingAddress.",
-					InternalError: err,
-				}
-			}
-		}
-		saveBillingAddress, _ := params.Args["saveBillingAddress"].(bool)
-
-		shippingAddressID, _ := params.Args["shippingAddressId"].(int)
-		var shippingAddress *db.Address = nil
-		if shippingAddressTemp, ok := params.Args["shippingAddress"]; ok {
-			shippingAddress = &db.Address{}
-			if err := ConvertObject(shippingAddressTemp, shippingAddress); err != nil {
-				return nil, &core.WrappedError{
-					Message:       "Could not decode shippingAddress.",
-					InternalError: err,
-				}
-			}
+		idempotencyKey, _ := params.Args["idempotencyKey"].(string)
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			return nil, InvalidIdempotencyKeyError
 		}
-		saveShippingAddress, _ := params.Args["saveShippingAddress"].(bool)
-
-		total := params.Args["total"].(int)
-
-		shippingRateID := params.Args["shippingRateId"].(string)
-
-		cart := dataloaders.CartKey{}
-		if err := ConvertObject(params.Args["variants"], &cart); err != nil {
+		fingerprint, err := checkoutFingerprint(userID, params.Args)
+		if err != nil {
 			return nil, &core.WrappedError{
-				Message:       "Could not convert variants argument.",
+				Message:       "Could not hash checkout.",
 				InternalError: err,
 			}
 		}
-
-		shippingAddress, err := getAddress(params.Context, shippingAddress, shippingAddressID, saveShippingAddress)
-		if err != nil {
-			return nil, err
-		}
-		billingAddress, err = getAddress(params.Context, billingAddress, billingAddressID, saveBillingAddress)
-		if err != nil {
-			return nil, err
+		if idempotencyKey != "" {
+			existing, err := findIdempotentTransaction(database, idempotencyKey, fingerprint)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				return existing, nil
+			}
 		}
 
-		subtotalCalculatedTemp, err := subtotalLoader.Load(params.Context, cart)()
-		if err != nil {
-			return nil, err
-		}
-		subtotalCalculated := subtotalCalculatedTemp.(int)
+		braintreeNonce := params.Args["braintreeNonce"].(string)
 
-		taxesCalculatedTemp, err := taxesLoader.Load(params.Context, *billingAddress)()
-		if err != nil || taxesCalculatedTemp == nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not load tax information",
-				InternalError: err,
+		var quote *db.CheckoutQuote
+		if quoteID, ok := params.Args["quoteId"].(string); ok && quoteID != "" {
+			quote, err = findCheckoutQuote(database, quoteID, userID)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			total, ok := params.Args["total"].(int)
+			if !ok || params.Args["variants"] == nil {
+				return nil, CheckoutQuoteRequiredError
 			}
-		}
-		taxRates := taxesCalculatedTemp.(*dataloaders.Taxes)
-		taxesCalculated := int(math.Round(float64(subtotalCalculated) * taxRates.TotalRate))
 
-		rate, err := shippoClient.RetrieveRate(shippingRateID)
-		if err != nil || rate == nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not retrieve shipping rate.",
-				InternalError: err,
+			quote, err = newCheckoutQuote(params.Context, params.Args)
+			if err != nil {
+				return nil, err
 			}
-		}
 
-		amount, err := strconv.ParseFloat(rate.Amount, 64)
-		if err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not convert shipping price.",
-				InternalError: err,
+			if quote.Total != total {
+				return nil, &core.WrappedError{
+					Message: "The provided total does not match the calculated ones.",
+				}
 			}
 		}
-		shippingCalculated := int(math.Round(amount * 100))
-
-		totalCalculated := subtotalCalculated + taxesCalculated + shippingCalculated
 
-		if totalCalculated != total {
+		shippingAddressTemp, err := addressLoader.Load(params.Context, dataloaders.IntKey(quote.ShippingAddressID))()
+		if err != nil {
 			return nil, &core.WrappedError{
-				Message: "The provided total does not match the calculated ones.",
+				Message:       "Could not find shipping address.",
+				InternalError: err,
 			}
 		}
+		shippingAddress := shippingAddressTemp.(*db.Address)
 
-		variantIDs := make(dataloader.Keys, len(cart))
-		for index, lineItem := range cart {
-			variantIDs[index] = dataloaders.IntKey(lineItem.VariantID)
+		variantIDs := make(dataloader.Keys, len(quote.LineItems))
+		for index, lineItem := range quote.LineItems {
+			variantIDs[index] = dataloaders.IntKey(lineItem.ProductVariantID)
 		}
 		variantsTemp, errs := productVariantLoader.LoadMany(params.Context, variantIDs)()
 		if errs != nil && len(errs) > 0 {
 			return nil, &core.WrappedError{
-				Message:       "Could not loaad variants.",
+				Message:       "Could not load variants.",
 				InternalError: dataloaders.HandleErrors(errs),
 			}
 		}
-		variantMap := map[int]*db.ProductVariant{}
-		for _, variantTemp := range variantsTemp {
-			variant := variantTemp.(*db.ProductVariant)
-			variantMap[variant.ID] = variant
-		}
 
-		result := db.Transaction{
-			Subtotal:     subtotalCalculated,
-			Taxes:        taxesCalculated,
-			Shipping:     shippingCalculated,
-			Total:        totalCalculated,
-			ShippoRateID: rate.ObjectID,
-			UserID:       userID,
-		}
-		if err := database.Insert(&result); err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not create transaction.",
-				InternalError: err,
-			}
-		}
+		paymentLineItems := make([]services.PaymentLineItem, len(quote.LineItems))
+		for index, lineItem := range quote.LineItems {
+			variant := variantsTemp[index].(*db.ProductVariant)
+			name := variant.Name
 
-		status := db.TransactionStatus{
-			CreatedAt:     time.Now(),
-			TransactionID: result.ID,
-			Status:        "RECEIVED",
-		}
-		if err := database.Insert(&status); err != nil {
-			database.Delete(&result)
-			database.ForceDelete(&result)
-			return nil, &core.WrappedError{
-				Message:       "Could not create transaction.",
-				InternalError: err,
-			}
-		}
+			if name == "" {
+				product, err := productLoader.Load(params.Context, dataloaders.IntKey(variant.ProductID))()
+				if err != nil || product == nil {
+					return nil, &core.WrappedError{
+						Message:       "Could not get product variant name.",
+						InternalError: err,
+					}
+				}
 
-		addressInfo := db.TransactionAddressInfo{
-			TransactionID:     result.ID,
-			BillingAddressID:  billingAddress.ID,
-			ShippingAddressID: shippingAddress.ID,
-		}
-		if err := database.Insert(&addressInfo); err != nil {
-			database.Delete(&status)
-			database.ForceDelete(&status)
-			database.Delete(&result)
-			database.ForceDelete(&result)
+				name = product.(*db.Product).Name
+			}
 
-			return nil, &core.WrappedError{
-				Message:       "Could not create transaction.",
-				InternalError: err,
+			paymentLineItems[index] = services.PaymentLineItem{
+				Name:        name,
+				Quantity:    lineItem.Quantity,
+				UnitAmount:  lineItem.Price,
+				TotalAmount: lineItem.Price * lineItem.Quantity,
 			}
 		}
-		addressInfo.BillingAddress = billingAddress
-		addressInfo.ShippingAddress = shippingAddress
-
-		createdLineItems := []*db.TransactionLineItem{}
-		for _, lineItem := range cart {
-			toCreate := db.TransactionLineItem{
-				TransactionID:    result.ID,
-				ProductVariantID: lineItem.VariantID,
-				Quantity:         lineItem.Quantity,
-				Price:            variantMap[lineItem.VariantID].Price,
-			}
-			if err = database.Insert(&toCreate); err != nil {
-				break
-			}
 
-			createdLineItems = append(createdLineItems, &toCreate)
-		}
+		result := db.Transaction{
+			Subtotal:           quote.Subtotal,
+			Taxes:              quote.Taxes,
+			Shipping:           quote.Shipping,
+			Total:              quote.Total,
+			UserID:             userID,
+			ShippingTax:        quote.ShippingTax,
+			ShippingTaxDetails: quote.ShippingTaxDetails,
+			TaxDocumentCode:    quote.TaxDocumentCode,
+			PaymentStatus:      PaymentStatusPending,
+		}
+		if idempotencyKey != "" {
+			result.IdempotencyKey = idempotencyKey
+			result.IdempotencyFingerprint = fingerprint
+		}
+		if len(quote.Shipments) == 1 {
+			result.ShippoRateID = quote.Shipments[0].ShippoRateID
+		}
+
+		// The order is saved as pending before it is paid for, so a sale always has an order to be
+		// matched with even if checkout is interrupted. See ReconcilePendingPaymentsField.
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			if err := tx.Insert(&result); err != nil {
+				return err
+			}
 
-		if err != nil {
-			for _, lineItem := range createdLineItems {
-				database.Delete(&lineItem)
-				database.ForceDelete(&lineItem)
+			if quote.ID != "" {
+				if err := useCheckoutQuote(tx, quote, result.ID); err != nil {
+					return err
+				}
 			}
 
-			database.Delete(&addressInfo)
-			database.ForceDelete(&addressInfo)
+			if err := appendTransactionStatus(tx, &db.TransactionStatus{
+				CreatedAt:     time.Now(),
+				TransactionID: result.ID,
+				Status:        TransactionStatusReceived,
+			}); err != nil {
+				return err
+			}
 
-			database.Delete(&result)
-			database.ForceDelete(&result)
+			if err := tx.Insert(&db.TransactionAddressInfo{
+				TransactionID:     result.ID,
+				BillingAddressID:  quote.BillingAddressID,
+				ShippingAddressID: quote.ShippingAddressID,
+			}); err != nil {
+				return err
+			}
 
-			return nil, &core.WrappedError{
-				Message:       "Could not create transaction.",
-				InternalError: err,
+			shipments := make([]*db.TransactionShipment, len(quote.Shipments))
+			for index, shipment := range quote.Shipments {
+				shipments[index] = &db.TransactionShipment{
+					TransactionID: result.ID,
+					OriginID:      shipment.OriginID,
+					Shipping:      shipment.Shipping,
+					ShippoRateID:  shipment.ShippoRateID,
+				}
+			}
+			if len(shipments) > 0 {
+				if err := tx.Insert(&shipments); err != nil {
+					return err
+				}
 			}
-		}
 
-		braintreeLineItems := make([]*braintree.TransactionLineItemRequest, len(createdLineItems))
-		for index, lineItem := range createdLineItems {
-			name := variantMap[lineItem.ProductVariantID].Name
+			parcels := []*db.TransactionParcel{}
+			for index, shipment := range quote.Shipments {
+				for _, parcel := range shipment.Parcels {
+					parcel.TransactionShipmentID = shipments[index].ID
+					parcels = append(parcels, parcel)
+				}
+			}
+			if len(parcels) > 0 {
+				if err := tx.Insert(&parcels); err != nil {
+					return err
+				}
+			}
 
-			if name == "" {
-				product, err := productLoader.Load(params.Context, dataloaders.IntKey(variantMap[lineItem.ProductVariantID].ProductID))()
-				if err != nil || product == nil {
-					return nil, &core.WrappedError{
-						Message:       "Could not get product variant name.",
-						InternalError: err,
-					}
+			for _, lineItem := range quote.LineItems {
+				toCreate := db.TransactionLineItem{
+					TransactionID:    result.ID,
+					ProductVariantID: lineItem.ProductVariantID,
+					Quantity:         lineItem.Quantity,
+					Price:            lineItem.Price,
+					TaxCode:          lineItem.TaxCode,
+					Tax:              lineItem.Tax,
+					TaxDetails:       lineItem.TaxDetails,
+				}
+				if err := tx.Insert(&toCreate); err != nil {
+					return err
 				}
 
-				name = product.(*db.Product).Name
+				if err := reserveStock(tx, &toCreate, userID); err != nil {
+					return err
+				}
 			}
 
-			braintreeLineItems[index] = &braintree.TransactionLineItemRequest{
-				Kind:        braintree.TransactionLineItemKindDebit,
-				Quantity:    braintree.NewDecimal(int64(lineItem.Quantity), 0),
-				Name:        name,
-				UnitAmount:  braintree.NewDecimal(int64(lineItem.Price), 2),
-				TotalAmount: braintree.NewDecimal(int64(lineItem.Price*lineItem.Quantity), 2),
+			return nil
+		}); err != nil {
+			if err == dataloaders.OutOfStockError || err == CheckoutQuoteUsedError {
+				return nil, err
 			}
-		}
-
-		extendedAddress := shippingAddress.Line2 + "," + shippingAddress.Line3
-		if extendedAddress == "," {
-			extendedAddress = ""
-		}
 
-		braintreeAddress := braintree.Address{
-			StreetAddress:   shippingAddress.Line1,
-			ExtendedAddress: extendedAddress,
-			Locality:        shippingAddress.City,
-			Region:          shippingAddress.Region,
-			PostalCode:      shippingAddress.PostalCode,
-		}
+			// A retry of the checkout got to it first.
+			if isIdempotencyKeyTaken(err) {
+				existing, err := findIdempotentTransaction(database, idempotencyKey, fingerprint)
+				if err != nil {
+					return nil, err
+				}
+				if existing != nil {
+					return existing, nil
+				}
+			}
 
-		if _, err := strconv.Atoi(shippingAddress.Country); err == nil {
-			braintreeAddress.CountryCodeNumeric = shippingAddress.Country
-		} else if len(shippingAddress.Country) == 2 {
-			braintreeAddress.CountryCodeAlpha2 = shippingAddress.Country
-		} else if len(shippingAddress.Country) == 3 {
-			braintreeAddress.CountryCodeAlpha3 = shippingAddress.Country
-		} else {
-			braintreeAddress.CountryName = shippingAddress.Country
+			return nil, &core.WrappedError{
+				Message:       "Could not create transaction.",
+				InternalError: err,
+			}
 		}
 
-		braintreeTransaction, err := braintreeClient.Transaction().Create(params.Context, &braintree.TransactionRequest{
-			Type:               "sale",
-			PaymentMethodNonce: braintreeNonce,
-			Options: &braintree.TransactionOptions{
-				SubmitForSettlement: true,
-			},
-			OrderId:         strconv.Itoa(result.ID),
-			Amount:          braintree.NewDecimal(int64(result.Total), 2),
-			TaxAmount:       braintree.NewDecimal(int64(result.Taxes), 2),
-			LineItems:       braintreeLineItems,
-			ShippingAddress: &braintreeAddress,
+		paymentTransaction, err := paymentGateway.Sale(params.Context, services.PaymentRequest{
+			OrderID:             strconv.Itoa(result.ID),
+			Nonce:               braintreeNonce,
+			Amount:              result.Total,
+			TaxAmount:           result.Taxes,
+			LineItems:           paymentLineItems,
+			ShippingAddress:     *shippingAddress,
+			SubmitForSettlement: !authorizeOnly,
 		})
 
 		if err != nil {
 			j, _ := json.MarshalIndent(err, "", "\t")
 			fmt.Println(string(j))
-			database.Delete(&result)
-			database.ForceDelete(&result)
 
-			return nil, err
+			// Only a sale the gateway declined is certain not to have been charged. After other errors
+			// the sale may still go through, so the order is left pending for
+			// ReconcilePendingPaymentsField to complete or delete.
+			if services.IsSaleDeclined(err) {
+				if abandonErr := abandonPendingOrder(database, &result, "Payment failed."); abandonErr != nil {
+					fmt.Println("Failed to delete unpaid pending transaction.")
+					fmt.Println(abandonErr)
+				}
+				return nil, err
+			}
+
+			found, findErr := paymentGateway.FindByOrderID(params.Context, strconv.Itoa(result.ID))
+			if findErr != nil {
+				fmt.Println("Failed to look up the payment of a failed sale. The transaction is left pending.")
+				fmt.Println(findErr)
+			}
+			if found == nil {
+				return nil, &core.WrappedError{
+					Message:       "Your payment could not be confirmed. The order will be completed if you were charged.",
+					InternalError: err,
+				}
+			}
+			paymentTransaction = found
 		}
 
-		result.BraintreeID = braintreeTransaction.Id
-		if err := database.Update(&result); err != nil {
-			fmt.Println("Failed to update transaction with Braintree ID.")
+		if err := completePendingOrder(params.Context, &result, paymentTransaction); err != nil {
+			fmt.Println("Failed to update transaction with payment transaction ID.")
 			fmt.Println(err)
 		}
 
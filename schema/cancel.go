@@ -0,0 +1,234 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/email"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+var TransactionAlreadyCancelledError = fmt.Errorf("The transaction has already been cancelled.")
+var TransactionNotCancellableError = fmt.Errorf("The transaction can no longer be cancelled.")
+var CancelRefundNotRecordedError = fmt.Errorf("The transaction was cancelled and its payment refunded, but the refund could not be recorded.")
+
+// Voids the payment of a transaction when it has not settled, and refunds what is left of it otherwise.
+func cancelTransactionPayment(ctx context.Context, transaction *db.Transaction, userID int) error {
+	database := ctx.Value("database").(*pg.DB)
+	paymentGateway := ctx.Value("paymentGateway").(services.PaymentGateway)
+	transactionRefundsLoader := ctx.Value("transactionRefunds").(*dataloader.Loader)
+
+	if transaction.BraintreeID == "" || transaction.PaymentStatus == services.PaymentStatusVoided {
+		return nil
+	}
+
+	if isPaymentAuthorized(transaction) || transaction.PaymentStatus == services.PaymentStatusSubmittedForSettlement {
+		paymentTransaction, err := paymentGateway.Void(ctx, transaction.BraintreeID)
+		if err == nil {
+			updatePaymentStatus(database, transaction, paymentTransaction.Status)
+			return nil
+		}
+
+		// Payments submitted for settlement may have settled since, in which case they are refunded.
+		if isPaymentAuthorized(transaction) {
+			return &core.WrappedError{
+				Message:       "Could not void payment.",
+				InternalError: err,
+			}
+		}
+	}
+
+	// Refunds made at the same time are locked out, so the remainder is still what is left.
+	var refund *db.TransactionRefund
+	err := database.RunInTransaction(func(tx *pg.Tx) error {
+		if err := tx.Model(transaction).WherePK().For("UPDATE").Select(); err != nil {
+			return &core.WrappedError{
+				Message:       "Could not find transaction.",
+				InternalError: err,
+			}
+		}
+
+		refunds := []*db.TransactionRefund{}
+		if err := tx.Model(&refunds).Where("transaction_id = ?", transaction.ID).Select(); err != nil {
+			return &core.WrappedError{
+				Message:       "Could not get refunds.",
+				InternalError: err,
+			}
+		}
+		refunded := 0
+		for _, previous := range refunds {
+			refunded += previous.Amount
+		}
+
+		if refunded >= transaction.Total {
+			return nil
+		}
+
+		paymentTransaction, err := paymentGateway.Refund(ctx, transaction.BraintreeID, transaction.Total-refunded)
+		if err != nil {
+			return &core.WrappedError{
+				Message:       "Could not refund payment.",
+				InternalError: err,
+			}
+		}
+
+		refund = &db.TransactionRefund{
+			CreatedAt:     time.Now(),
+			TransactionID: transaction.ID,
+			GatewayID:     paymentTransaction.ID,
+			Amount:        transaction.Total - refunded,
+			Reason:        "Order cancelled.",
+			LineItems:     []*db.RefundLineItem{},
+			UserID:        userID,
+		}
+		if err := insertTransactionRefund(tx, refund); err != nil {
+			return err
+		}
+
+		status := db.TransactionStatus{
+			CreatedAt:     refund.CreatedAt,
+			TransactionID: transaction.ID,
+			Status:        TransactionStatusPartiallyRefunded,
+		}
+		if refunded+refund.Amount == transaction.Total {
+			status.Status = TransactionStatusRefunded
+		}
+
+		return appendTransactionStatus(tx, &status)
+	})
+	transactionRefundsLoader.Clear(ctx, dataloaders.IntKey(transaction.ID))
+
+	if err != nil && refund != nil {
+		fmt.Println("Failed to record refund " + refund.GatewayID + ".")
+		fmt.Println(err)
+
+		// The payment was refunded, so the refund is kept without the status.
+		refund.ID = 0
+		if err := insertTransactionRefund(database, refund); err != nil {
+			fmt.Println("Failed to record refund " + refund.GatewayID + ".")
+			fmt.Println(err)
+		}
+
+		return CancelRefundNotRecordedError
+	}
+
+	return err
+}
+
+var CancelTransactionField = &graphql.Field{
+	Type:        TransactionType,
+	Description: "Cancel a transaction, void or refund its payment and put its stock back. Customers can cancel their own orders until their payment is captured or they ship, admins can cancel any order.",
+	Args: graphql.FieldConfigArgument{
+		"transactionId": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+		userLoader := params.Context.Value("user").(*dataloader.Loader)
+		emailClient := params.Context.Value("email").(email.Client)
+		baseUrl := params.Context.Value("baseUrl").(string)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+
+		// The order is claimed as cancelled and its stock put back under a lock on the transaction,
+		// so a cancellation running at the same time finds it already cancelled.
+		transaction := db.Transaction{ID: params.Args["transactionId"].(int)}
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			if err := tx.
+				Model(&transaction).
+				WherePK().
+				For("UPDATE").
+				Select(); err != nil {
+				return &core.WrappedError{
+					Message:       "Could not find transaction.",
+					InternalError: err,
+				}
+			}
+
+			if claims.Role != "ADMIN" && transaction.UserID != claims.ID {
+				return auth.NotAuthorizedError
+			}
+
+			status, err := currentTransactionStatus(tx, transaction.ID)
+			if err != nil {
+				return err
+			}
+			if status == TransactionStatusCancelled {
+				return TransactionAlreadyCancelledError
+			}
+			if isPaymentPending(&transaction) {
+				return PaymentPendingError
+			}
+			if err := validateTransactionStatusTransition(status, TransactionStatusCancelled); err != nil {
+				return TransactionNotCancellableError
+			}
+			if claims.Role != "ADMIN" && status != TransactionStatusReceived && status != TransactionStatusAuthorized {
+				return TransactionNotCancellableError
+			}
+
+			lineItems := []*db.TransactionLineItem{}
+			if err := tx.
+				Model(&lineItems).
+				Where("transaction_line_item.transaction_id = ?", transaction.ID).
+				Select(); err != nil {
+				return &core.WrappedError{
+					Message:       "Could not get line items to put back in stock.",
+					InternalError: err,
+				}
+			}
+			for _, lineItem := range lineItems {
+				if err := releaseStock(tx, lineItem, claims.ID, "Order cancelled."); err != nil {
+					return err
+				}
+			}
+
+			return appendTransactionStatus(tx, &db.TransactionStatus{
+				TransactionID: transaction.ID,
+				Status:        TransactionStatusCancelled,
+			})
+		}); err != nil {
+			return nil, err
+		}
+
+		if err := cancelTransactionPayment(params.Context, &transaction, claims.ID); err == CancelRefundNotRecordedError {
+			return nil, err
+		} else if err != nil {
+			return nil, &core.WrappedError{
+				Message:       "The transaction was cancelled, but its payment could not be voided or refunded. Refund it from the transaction.",
+				InternalError: err,
+			}
+		}
+
+		if transaction.UserID > 0 {
+			tempUser, err := userLoader.Load(params.Context, dataloaders.IntKey(transaction.UserID))()
+			if err != nil {
+				fmt.Println("Failed to find user to email cancelled order to.")
+				fmt.Println(err)
+			} else {
+				toSend, err := email.NewCancelledEmail(baseUrl, transaction.ID)
+				if err != nil {
+					fmt.Println("Failed create cancelled email.")
+					fmt.Println(err)
+				} else if err := emailClient.SendMail(tempUser.(*db.User).Email, "Your order has been cancelled.", toSend); err != nil {
+					fmt.Println("Failed to send cancelled email.")
+					fmt.Println(err)
+				}
+			}
+		}
+
+		return &transaction, nil
+	},
+}
@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+var TransactionNotAuthorizedError = fmt.Errorf("The transaction does not have an authorized payment to capture.")
+var TransactionNotCapturedError = fmt.Errorf("The payment of the transaction has not been captured.")
+
+func isPaymentAuthorized(transaction *db.Transaction) bool {
+	return transaction.PaymentStatus == services.PaymentStatusAuthorized
+}
+
+func updatePaymentStatus(database *pg.DB, transaction *db.Transaction, paymentStatus string) {
+	transaction.PaymentStatus = paymentStatus
+	if _, err := database.
+		Model(transaction).
+		Column("payment_status", "updated_at").
+		WherePK().
+		Update(); err != nil {
+		fmt.Println("Failed to update transaction payment status.")
+		fmt.Println(err)
+	}
+}
+
+// Submits an authorized payment for settlement and commits its taxes. Payments that were captured
+// at checkout are left as they are. Failed captures are recorded as transaction statuses.
+func captureTransactionPayment(ctx context.Context, transaction *db.Transaction) error {
+	database := ctx.Value("database").(*pg.DB)
+	paymentGateway := ctx.Value("paymentGateway").(services.PaymentGateway)
+
+	if !isPaymentAuthorized(transaction) {
+		return nil
+	}
+
+	paymentTransaction, err := paymentGateway.Capture(ctx, transaction.BraintreeID, 0)
+	if err != nil {
+		status := TransactionStatusCaptureFailed
+		if retrieved, retrieveErr := paymentGateway.Retrieve(ctx, transaction.BraintreeID); retrieveErr == nil {
+			if retrieved.Status == services.PaymentStatusAuthorizationExpired {
+				status = TransactionStatusAuthorizationExpired
+			}
+			updatePaymentStatus(database, transaction, retrieved.Status)
+		}
+		recordTransactionStatus(database, transaction.ID, status)
+
+		return &core.WrappedError{
+			Message:       "Could not capture payment.",
+			InternalError: err,
+		}
+	}
+
+	updatePaymentStatus(database, transaction, paymentTransaction.Status)
+	recordTransactionStatus(database, transaction.ID, TransactionStatusCaptured)
+
+	commitTransactionTaxes(ctx, transaction)
+
+	return nil
+}
+
+var CaptureTransactionField = &graphql.Field{
+	Type:        TransactionType,
+	Description: "Capture the authorized payment of a transaction.",
+	Args: graphql.FieldConfigArgument{
+		"transactionId": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		transactionLoader := params.Context.Value("transaction").(*dataloader.Loader)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		tempTransaction, err := transactionLoader.Load(params.Context, dataloaders.IntKey(params.Args["transactionId"].(int)))()
+		if err != nil {
+			return nil, err
+		}
+		transaction := tempTransaction.(*db.Transaction)
+
+		if !isPaymentAuthorized(transaction) {
+			return nil, TransactionNotAuthorizedError
+		}
+
+		if err := captureTransactionPayment(params.Context, transaction); err != nil {
+			return nil, err
+		}
+
+		return transaction, nil
+	},
+}
@@ -1,13 +1,24 @@
 package schema
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
 	"github.com/graph-gophers/dataloader"
 	"github.com/graphql-go/graphql"
+	"github.com/jacob-ebey/golang-ecomm/auth"
 	"github.com/jacob-ebey/golang-ecomm/dataloaders"
 	"github.com/jacob-ebey/golang-ecomm/db"
 	core "github.com/jacob-ebey/graphql-core"
 )
 
+var CartNotFoundError = fmt.Errorf("Could not find the cart.")
+var CartVariantNotFoundError = fmt.Errorf("The product variant could not be found.")
+var SubtotalCartRequiredError = fmt.Errorf("The variants or a cart must be provided.")
+
 var CartInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
 	Name: "CartInput",
 	Fields: graphql.InputObjectConfigFieldMap{
@@ -20,25 +31,119 @@ var CartInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
 	},
 })
 
+var CartItemType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "CartItem",
+	Fields: graphql.Fields{
+		"variantId": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"quantity": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"variant": &graphql.Field{
+			Type: ProductVariantType,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				productVariant := params.Context.Value("productVariant").(*dataloader.Loader)
+
+				item := params.Source.(dataloaders.CartVariant)
+
+				thunk := productVariant.Load(params.Context, dataloaders.IntKey(item.VariantID))
+
+				return func() (interface{}, error) {
+					return thunk()
+				}, nil
+			},
+		},
+	},
+})
+
+var CartType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Cart",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"token": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The token to find a guest cart with. Carts of signed in customers do not have one.",
+		},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+		},
+		"updatedAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+		},
+		"items": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(CartItemType))),
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				cart := params.Source.(*db.Cart)
+
+				return cartKey(cart), nil
+			},
+		},
+		"subtotal": &graphql.Field{
+			Type: graphql.Int,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				subtotal := params.Context.Value("subtotal").(*dataloader.Loader)
+
+				cart := params.Source.(*db.Cart)
+
+				thunk := subtotal.Load(params.Context, cartKey(cart))
+
+				return func() (interface{}, error) {
+					return thunk()
+				}, nil
+			},
+		},
+	},
+})
+
 var SubtotalField = &graphql.Field{
 	Type:        graphql.Int,
-	Description: "The subtotal for the provided variants and their quantities.",
+	Description: "The subtotal for the provided variants and their quantities, or for the items of a cart.",
 	Args: graphql.FieldConfigArgument{
 		"variants": &graphql.ArgumentConfig{
-			Type: graphql.NewNonNull(graphql.NewList(
+			Type: graphql.NewList(
 				graphql.NewNonNull(CartInputSchema),
-			)),
+			),
+		},
+		"cartId": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "The cart to total instead of the variants.",
+		},
+		"cartToken": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The token of the cart, required for guest carts.",
 		},
 	},
 	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
 		subtotal := params.Context.Value("subtotal").(*dataloader.Loader)
+		claims := params.Context.Value("claims").(*auth.Claims)
 
 		cart := dataloaders.CartKey{}
-		if err := ConvertObject(params.Args["variants"], &cart); err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not convert variants argument.",
-				InternalError: err,
+		if cartID, ok := params.Args["cartId"].(int); ok {
+			cartToken, _ := params.Args["cartToken"].(string)
+
+			found, err := findCart(database, cartUserID(claims), cartToken)
+			if err != nil {
+				return nil, err
 			}
+			if found == nil || found.ID != cartID {
+				return nil, CartNotFoundError
+			}
+
+			cart = cartKey(found)
+		} else if params.Args["variants"] != nil {
+			if err := ConvertObject(params.Args["variants"], &cart); err != nil {
+				return nil, &core.WrappedError{
+					Message:       "Could not convert variants argument.",
+					InternalError: err,
+				}
+			}
+
+		} else {
+			return nil, SubtotalCartRequiredError
 		}
 
 		thunk := subtotal.Load(params.Context, cart)
@@ -75,3 +180,363 @@ var TaxesField = &graphql.Field{
 		}, nil
 	},
 }
+
+var CartField = &graphql.Field{
+	Type:        CartType,
+	Description: "Get the cart of the signed in customer, or a guest cart by its token.",
+	Args: graphql.FieldConfigArgument{
+		"token": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The token of a guest cart. Ignored for signed in customers.",
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+		claims := params.Context.Value("claims").(*auth.Claims)
+
+		token, _ := params.Args["token"].(string)
+
+		return findCart(database, cartUserID(claims), token)
+	},
+}
+
+func cartArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"token": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The token of a guest cart. A new guest cart is started without one.",
+		},
+		"variantId": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	}
+}
+
+var AddCartItemField = &graphql.Field{
+	Type:        graphql.NewNonNull(CartType),
+	Description: "Add a quantity of a product variant to the cart.",
+	Args: func() graphql.FieldConfigArgument {
+		args := cartArgs()
+		args["quantity"] = &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: 1,
+		}
+		return args
+	}(),
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		variantID := params.Args["variantId"].(int)
+		quantity := params.Args["quantity"].(int)
+
+		if quantity < 1 {
+			return nil, dataloaders.InvalidQuantityError
+		}
+
+		return changeCart(params, true, func(tx *pg.Tx, cart *db.Cart) error {
+			if err := checkCartVariant(tx, variantID); err != nil {
+				return err
+			}
+
+			return addCartQuantity(tx, cart.ID, variantID, quantity)
+		})
+	},
+}
+
+var UpdateCartItemField = &graphql.Field{
+	Type:        graphql.NewNonNull(CartType),
+	Description: "Set the quantity of a product variant in the cart. A quantity of 0 removes it.",
+	Args: func() graphql.FieldConfigArgument {
+		args := cartArgs()
+		args["quantity"] = &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		}
+		return args
+	}(),
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		variantID := params.Args["variantId"].(int)
+		quantity := params.Args["quantity"].(int)
+
+		if quantity < 0 {
+			return nil, dataloaders.InvalidQuantityError
+		}
+
+		return changeCart(params, quantity > 0, func(tx *pg.Tx, cart *db.Cart) error {
+			if quantity == 0 {
+				return removeCartVariant(tx, cart.ID, variantID)
+			}
+
+			if err := checkCartVariant(tx, variantID); err != nil {
+				return err
+			}
+
+			return setCartQuantity(tx, cart.ID, variantID, quantity)
+		})
+	},
+}
+
+var RemoveCartItemField = &graphql.Field{
+	Type:        graphql.NewNonNull(CartType),
+	Description: "Remove a product variant from the cart.",
+	Args:        cartArgs(),
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		variantID := params.Args["variantId"].(int)
+
+		return changeCart(params, false, func(tx *pg.Tx, cart *db.Cart) error {
+			return removeCartVariant(tx, cart.ID, variantID)
+		})
+	},
+}
+
+var EmptyCartField = &graphql.Field{
+	Type:        graphql.NewNonNull(CartType),
+	Description: "Remove everything from the cart, for example after checking out.",
+	Args: graphql.FieldConfigArgument{
+		"token": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The token of a guest cart.",
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		return changeCart(params, false, func(tx *pg.Tx, cart *db.Cart) error {
+			_, err := tx.
+				Model((*db.CartItem)(nil)).
+				Where("cart_id = ?", cart.ID).
+				Delete()
+			return err
+		})
+	},
+}
+
+// Runs a change to the cart the request is for in a transaction and returns the changed cart. The
+// cart is created first when create is set, otherwise a missing cart is an error.
+func changeCart(params graphql.ResolveParams, create bool, change func(tx *pg.Tx, cart *db.Cart) error) (*db.Cart, error) {
+	database := params.Context.Value("database").(*pg.DB)
+	claims := params.Context.Value("claims").(*auth.Claims)
+
+	userID := cartUserID(claims)
+	token, _ := params.Args["token"].(string)
+
+	var cart *db.Cart
+	if err := database.RunInTransaction(func(tx *pg.Tx) error {
+		var err error
+		if create {
+			cart, err = findOrCreateCart(tx, userID, token)
+		} else {
+			cart, err = findCart(tx, userID, token)
+		}
+		if err != nil {
+			return err
+		}
+		if cart == nil {
+			return CartNotFoundError
+		}
+
+		if err := change(tx, cart); err != nil {
+			return err
+		}
+
+		if _, err := tx.Model(cart).Column("updated_at").WherePK().Update(); err != nil {
+			return err
+		}
+
+		cart, err = findCart(tx, cart.UserID, cart.Token)
+		return err
+	}); err != nil {
+		if _, ok := err.(*core.WrappedError); ok || err == CartNotFoundError || err == CartVariantNotFoundError {
+			return nil, err
+		}
+
+		return nil, &core.WrappedError{
+			Message:       "Could not update cart.",
+			InternalError: err,
+		}
+	}
+
+	return cart, nil
+}
+
+func cartUserID(claims *auth.Claims) int {
+	if claims == nil {
+		return 0
+	}
+
+	return claims.ID
+}
+
+func cartKey(cart *db.Cart) dataloaders.CartKey {
+	key := make(dataloaders.CartKey, len(cart.Items))
+	for index, item := range cart.Items {
+		key[index] = dataloaders.CartVariant{
+			VariantID: item.ProductVariantID,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	return key
+}
+
+// Finds the cart of a signed in customer, or a guest cart by its token. Returns nil when there is
+// no cart.
+func findCart(database orm.DB, userID int, token string) (*db.Cart, error) {
+	cart := db.Cart{}
+	query := database.
+		Model(&cart).
+		Relation("Items", func(query *orm.Query) (*orm.Query, error) {
+			return query.OrderExpr("cart_item.id ASC"), nil
+		})
+
+	if userID != 0 {
+		query = query.Where("cart.user_id = ?", userID)
+	} else if token != "" {
+		query = query.Where("cart.token = ?", token).Where("cart.user_id IS NULL")
+	} else {
+		return nil, nil
+	}
+
+	if err := query.Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, &core.WrappedError{
+			Message:       "Could not get cart.",
+			InternalError: err,
+		}
+	}
+
+	return &cart, nil
+}
+
+// Guests whose token is unknown, for example because their cart was merged into an account, are
+// given a new cart with a new token.
+func findOrCreateCart(tx *pg.Tx, userID int, token string) (*db.Cart, error) {
+	cart, err := findCart(tx, userID, token)
+	if err != nil || cart != nil {
+		return cart, err
+	}
+
+	cart = &db.Cart{
+		UserID: userID,
+	}
+	if userID == 0 {
+		cart.Token, err = newCartToken()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Another request may have created the customer's cart in the meantime.
+	if _, err := tx.Model(cart).OnConflict("DO NOTHING").Insert(); err != nil {
+		return nil, err
+	}
+
+	return findCart(tx, userID, cart.Token)
+}
+
+func newCartToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", &core.WrappedError{
+			Message:       "Could not create cart token.",
+			InternalError: err,
+		}
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+func checkCartVariant(database orm.DB, variantID int) error {
+	exists, err := database.
+		Model((*db.ProductVariant)(nil)).
+		Where("product_variant.id = ?", variantID).
+		Exists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return CartVariantNotFoundError
+	}
+
+	return nil
+}
+
+func addCartQuantity(database orm.DB, cartID int, variantID int, quantity int) error {
+	_, err := database.
+		Model(&db.CartItem{
+			CartID:           cartID,
+			ProductVariantID: variantID,
+			Quantity:         quantity,
+		}).
+		OnConflict("(cart_id, product_variant_id) DO UPDATE").
+		Set("quantity = cart_item.quantity + EXCLUDED.quantity").
+		Insert()
+	return err
+}
+
+func setCartQuantity(database orm.DB, cartID int, variantID int, quantity int) error {
+	_, err := database.
+		Model(&db.CartItem{
+			CartID:           cartID,
+			ProductVariantID: variantID,
+			Quantity:         quantity,
+		}).
+		OnConflict("(cart_id, product_variant_id) DO UPDATE").
+		Set("quantity = EXCLUDED.quantity").
+		Insert()
+	return err
+}
+
+func removeCartVariant(database orm.DB, cartID int, variantID int) error {
+	_, err := database.
+		Model((*db.CartItem)(nil)).
+		Where("cart_id = ?", cartID).
+		Where("product_variant_id = ?", variantID).
+		Delete()
+	return err
+}
+
+// Moves the items of a guest cart into the cart of a customer that just signed in, adding up the
+// quantities of variants in both. The guest cart becomes the customer's cart when they have none.
+func mergeGuestCart(database *pg.DB, userID int, token string) error {
+	return database.RunInTransaction(func(tx *pg.Tx) error {
+		guest, err := findCart(tx, 0, token)
+		if err != nil || guest == nil {
+			return err
+		}
+
+		existing, err := findCart(tx, userID, "")
+		if err != nil {
+			return err
+		}
+
+		if existing == nil {
+			guest.UserID = userID
+			guest.Token = ""
+			_, err := tx.
+				Model(guest).
+				Column("user_id", "token", "updated_at").
+				WherePK().
+				Where("cart.user_id IS NULL").
+				Update()
+			return err
+		}
+
+		for _, item := range guest.Items {
+			if err := addCartQuantity(tx, existing.ID, item.ProductVariantID, item.Quantity); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.
+			Model((*db.CartItem)(nil)).
+			Where("cart_id = ?", guest.ID).
+			Delete(); err != nil {
+			return err
+		}
+		if _, err := tx.Model(guest).WherePK().Delete(); err != nil {
+			return err
+		}
+
+		_, err = tx.Model(existing).Column("updated_at").WherePK().Update()
+		return err
+	})
+}
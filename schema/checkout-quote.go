@@ -0,0 +1,443 @@
+package schema
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+// How long the prices, taxes and shipping of a quote are honored for.
+const checkoutQuoteLifetime = 15 * time.Minute
+
+var CheckoutQuoteNotFoundError = fmt.Errorf("Could not find the checkout quote.")
+var CheckoutQuoteExpiredError = fmt.Errorf("The checkout quote has expired. Request a new quote to see the current prices.")
+var CheckoutQuoteUsedError = fmt.Errorf("An order was already placed with the checkout quote.")
+var CheckoutQuoteRequiredError = fmt.Errorf("A quote, or the variants and total of the order, must be provided.")
+var EmptyCartError = fmt.Errorf("The order must contain at least one variant.")
+var ShippingRateExpiredError = fmt.Errorf("The shipping rate has expired. Estimate shipping again to get a current rate.")
+var ShippingRateMismatchError = fmt.Errorf("The shipping rate was estimated for a different cart or address. Estimate shipping again to get a rate for this order.")
+
+var CheckoutQuoteLineItemType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "CheckoutQuoteLineItem",
+	Fields: graphql.Fields{
+		"productVariantId": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"quantity": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"price": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"tax": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"taxDetails": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(TaxDetailType)),
+		},
+		"variant": &graphql.Field{
+			Type: ProductVariantType,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				productVariant := params.Context.Value("productVariant").(*dataloader.Loader)
+
+				lineItem := params.Source.(*db.CheckoutQuoteLineItem)
+
+				thunk := productVariant.Load(params.Context, dataloaders.IntKey(lineItem.ProductVariantID))
+
+				return func() (interface{}, error) {
+					return thunk()
+				}, nil
+			},
+		},
+	},
+})
+
+var CheckoutQuoteType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "CheckoutQuote",
+	Description: "The prices, taxes and shipping of a checkout, honored by submitBraintreeTransaction until the quote expires. Amounts are in cents.",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+		},
+		"expiresAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+		},
+		"subtotal": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"taxes": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shipping": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shippingTax": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shippingTaxDetails": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(TaxDetailType)),
+		},
+		"total": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"lineItems": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(CheckoutQuoteLineItemType))),
+		},
+	},
+})
+
+// The arguments that describe what is ordered, where it ships and how. Both createCheckoutQuote
+// and submitBraintreeTransaction take them.
+func checkoutArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"billingAddressId": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+		"billingAddress": &graphql.ArgumentConfig{
+			Type: AddressInputSchema,
+		},
+		"saveBillingAddress": &graphql.ArgumentConfig{
+			Type: graphql.Boolean,
+		},
+		"shippingAddressId": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+		"shippingAddress": &graphql.ArgumentConfig{
+			Type: AddressInputSchema,
+		},
+		"saveShippingAddress": &graphql.ArgumentConfig{
+			Type: graphql.Boolean,
+		},
+		"shippingRateId": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "The shipping rate for a cart that ships from a single origin.",
+		},
+		"shippingRateIds": &graphql.ArgumentConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+			Description: "One shipping rate per shipping estimation group, in the same order as the groups.",
+		},
+	}
+}
+
+func newCheckoutQuoteID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", &core.WrappedError{
+			Message:       "Could not create checkout quote ID.",
+			InternalError: err,
+		}
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+// Addresses that are not saved to the customer's account are still stored, without a user, so
+// quotes and transactions can reference them.
+func getCheckoutAddress(ctx context.Context, address *db.Address, addressID int, saveAddress bool) (*db.Address, error) {
+	database := ctx.Value("database").(*pg.DB)
+
+	address, err := getAddress(ctx, address, addressID, saveAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if address.ID == 0 {
+		address.UserID = 0
+		if err := database.Insert(address); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not save address.",
+				InternalError: err,
+			}
+		}
+	}
+
+	return address, nil
+}
+
+// Prices the cart of the checkout arguments, rates its shipments with the selected rates and
+// calculates its taxes. The quote is not saved.
+func newCheckoutQuote(ctx context.Context, args map[string]interface{}) (*db.CheckoutQuote, error) {
+	subtotalLoader := ctx.Value("subtotal").(*dataloader.Loader)
+	productVariantLoader := ctx.Value("productVariant").(*dataloader.Loader)
+
+	claims := ctx.Value("claims").(*auth.Claims)
+	userID := 0
+	if claims != nil {
+		userID = claims.ID
+	}
+
+	billingAddressID, _ := args["billingAddressId"].(int)
+	var billingAddress *db.Address = nil
+	if billingAddressTemp, ok := args["billingAddress"]; ok {
+		billingAddress = &db.Address{}
+		if err := ConvertObject(billingAddressTemp, billingAddress); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not decode billingAddress.",
+				InternalError: err,
+			}
+		}
+	}
+	saveBillingAddress, _ := args["saveBillingAddress"].(bool)
+
+	shippingAddressID, _ := args["shippingAddressId"].(int)
+	var shippingAddress *db.Address = nil
+	if shippingAddressTemp, ok := args["shippingAddress"]; ok {
+		shippingAddress = &db.Address{}
+		if err := ConvertObject(shippingAddressTemp, shippingAddress); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not decode shippingAddress.",
+				InternalError: err,
+			}
+		}
+	}
+	saveShippingAddress, _ := args["saveShippingAddress"].(bool)
+
+	shippingRateIDs := []string{}
+	if shippingRateID, ok := args["shippingRateId"].(string); ok && shippingRateID != "" {
+		shippingRateIDs = append(shippingRateIDs, shippingRateID)
+	}
+	if shippingRateIDsTemp, ok := args["shippingRateIds"]; ok {
+		additionalRateIDs := []string{}
+		if err := ConvertObject(shippingRateIDsTemp, &additionalRateIDs); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not convert shippingRateIds argument.",
+				InternalError: err,
+			}
+		}
+		shippingRateIDs = append(shippingRateIDs, additionalRateIDs...)
+	}
+
+	cart := dataloaders.CartKey{}
+	if err := ConvertObject(args["variants"], &cart); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not convert variants argument.",
+			InternalError: err,
+		}
+	}
+	if len(cart) == 0 {
+		return nil, EmptyCartError
+	}
+
+	shippingAddress, err := getCheckoutAddress(ctx, shippingAddress, shippingAddressID, saveShippingAddress)
+	if err != nil {
+		return nil, err
+	}
+	billingAddress, err = getCheckoutAddress(ctx, billingAddress, billingAddressID, saveBillingAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	subtotalTemp, err := subtotalLoader.Load(ctx, cart)()
+	if err != nil {
+		return nil, err
+	}
+	subtotal := subtotalTemp.(int)
+
+	shippingGroups, err := dataloaders.GroupCartByOrigin(ctx, cart)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shippingRateIDs) != len(shippingGroups) {
+		return nil, ShippingRateCountError
+	}
+
+	shipments := make([]*db.CheckoutQuoteShipment, len(shippingGroups))
+	shipping := 0
+	for index, group := range shippingGroups {
+		estimation, err := retrieveShippingEstimation(ctx, shippingRateIDs[index])
+		if err != nil {
+			return nil, err
+		}
+		if !estimation.ExpiresAt.IsZero() && time.Now().After(estimation.ExpiresAt) {
+			return nil, ShippingRateExpiredError
+		}
+
+		packed, err := dataloaders.PackCart(ctx, group.Variants)
+		if err != nil {
+			return nil, err
+		}
+		if !estimation.RatedFor(*group.Origin, *shippingAddress, dataloaders.NewShippingParcels(packed)) {
+			return nil, ShippingRateMismatchError
+		}
+
+		shipping += estimation.Price
+		shipments[index] = &db.CheckoutQuoteShipment{
+			OriginID:     group.Origin.ID,
+			Shipping:     estimation.Price,
+			ShippoRateID: estimation.ID,
+			Parcels:      dataloaders.NewTransactionParcels(packed),
+		}
+	}
+
+	taxDocumentCode, err := newTaxDocumentCode()
+	if err != nil {
+		return nil, err
+	}
+
+	taxCalculation, err := calculateCartTaxes(
+		ctx, *shippingAddress, cart, shipping, taxDocumentCode, customerCodeForClaims(claims))
+	if err != nil {
+		return nil, err
+	}
+
+	variantIDs := make(dataloader.Keys, len(cart))
+	for index, lineItem := range cart {
+		variantIDs[index] = dataloaders.IntKey(lineItem.VariantID)
+	}
+	variantsTemp, errs := productVariantLoader.LoadMany(ctx, variantIDs)()
+	if errs != nil && len(errs) > 0 {
+		return nil, &core.WrappedError{
+			Message:       "Could not load variants.",
+			InternalError: dataloaders.HandleErrors(errs),
+		}
+	}
+
+	// Tax lines are matched to the cart by variant, in order for variants that are in the cart more
+	// than once, so they do not depend on the order the cart was sorted in.
+	taxLines := map[int][]*services.TaxLineResult{}
+	for _, taxLine := range taxCalculation.Lines {
+		taxLines[taxLine.ID] = append(taxLines[taxLine.ID], taxLine)
+	}
+
+	lineItems := make([]*db.CheckoutQuoteLineItem, len(cart))
+	for index, lineItem := range cart {
+		variant := variantsTemp[index].(*db.ProductVariant)
+
+		taxCode, err := taxCodeForVariant(ctx, variant)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(taxLines[lineItem.VariantID]) == 0 {
+			return nil, &core.WrappedError{
+				Message:       "Could not calculate taxes.",
+				InternalError: fmt.Errorf("No tax line for variant %d.", lineItem.VariantID),
+			}
+		}
+		taxLine := taxLines[lineItem.VariantID][0]
+		taxLines[lineItem.VariantID] = taxLines[lineItem.VariantID][1:]
+
+		lineItems[index] = &db.CheckoutQuoteLineItem{
+			ProductVariantID: lineItem.VariantID,
+			Quantity:         lineItem.Quantity,
+			Price:            variant.Price,
+			TaxCode:          taxCode,
+			Tax:              taxLine.Tax,
+			TaxDetails:       taxLine.Details,
+		}
+	}
+
+	return &db.CheckoutQuote{
+		UserID:             userID,
+		BillingAddressID:   billingAddress.ID,
+		ShippingAddressID:  shippingAddress.ID,
+		LineItems:          lineItems,
+		Shipments:          shipments,
+		Subtotal:           subtotal,
+		Taxes:              taxCalculation.TotalTax,
+		Shipping:           shipping,
+		ShippingTax:        taxCalculation.ShippingTax,
+		ShippingTaxDetails: taxCalculation.ShippingDetails,
+		Total:              subtotal + taxCalculation.TotalTax + shipping,
+		TaxDocumentCode:    taxDocumentCode,
+	}, nil
+}
+
+// Finds a quote the user can still place an order with.
+func findCheckoutQuote(database *pg.DB, quoteID string, userID int) (*db.CheckoutQuote, error) {
+	quote := db.CheckoutQuote{ID: quoteID}
+	if err := database.Model(&quote).WherePK().Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, CheckoutQuoteNotFoundError
+		}
+
+		return nil, &core.WrappedError{
+			Message:       "Could not get checkout quote.",
+			InternalError: err,
+		}
+	}
+
+	if quote.UserID != userID {
+		return nil, auth.NotAuthorizedError
+	}
+	if quote.TransactionID != 0 {
+		return nil, CheckoutQuoteUsedError
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		return nil, CheckoutQuoteExpiredError
+	}
+
+	return &quote, nil
+}
+
+// Claims a quote for a transaction as part of placing the order. Fails when another order claimed
+// it first.
+func useCheckoutQuote(tx *pg.Tx, quote *db.CheckoutQuote, transactionID int) error {
+	result, err := tx.
+		Model(quote).
+		Set("transaction_id = ?", transactionID).
+		WherePK().
+		Where("transaction_id IS NULL").
+		Update()
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return CheckoutQuoteUsedError
+	}
+
+	quote.TransactionID = transactionID
+	return nil
+}
+
+var CreateCheckoutQuoteField = &graphql.Field{
+	Type:        CheckoutQuoteType,
+	Description: "Lock in the prices, taxes and shipping of a checkout. Pass the quote's ID to submitBraintreeTransaction to be charged exactly what it says.",
+	Args: func() graphql.FieldConfigArgument {
+		args := checkoutArgs()
+		args["variants"] = &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(CartInputSchema))),
+		}
+		return args
+	}(),
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		quote, err := newCheckoutQuote(params.Context, params.Args)
+		if err != nil {
+			return nil, err
+		}
+
+		quote.ID, err = newCheckoutQuoteID()
+		if err != nil {
+			return nil, err
+		}
+		quote.CreatedAt = time.Now()
+		quote.ExpiresAt = quote.CreatedAt.Add(checkoutQuoteLifetime)
+
+		if err := database.Insert(quote); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not save checkout quote.",
+				InternalError: err,
+			}
+		}
+
+		return quote, nil
+	},
+}
@@ -0,0 +1,242 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/documents"
+)
+
+const (
+	DocumentInvoice     = "invoice"
+	DocumentPackingSlip = "packing-slip"
+)
+
+// How long a document link can be used for. Links are signed when they are resolved, so a fresh one
+// is handed out every time the transaction is queried.
+const documentLinkLifetime = time.Hour
+
+// Signs a link to a document of a transaction.
+func documentURL(ctx context.Context, document string, transactionID int) string {
+	baseUrl := ctx.Value("baseUrl").(string)
+	signer := ctx.Value("auth").(auth.Signer)
+
+	expires := time.Now().Add(documentLinkLifetime).Unix()
+	signature := signer.Sign(fmt.Sprintf("%s:%d:%d", document, transactionID, expires))
+
+	return fmt.Sprintf("%s/documents/%s?transaction=%d&expires=%d&signature=%s", baseUrl, document, transactionID, expires, signature)
+}
+
+var InvoiceURLField = &graphql.Field{
+	Type:        graphql.String,
+	Description: "A link to download the invoice of the order as a PDF. The link expires after an hour.",
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+
+		transaction := params.Source.(*db.Transaction)
+		if claims.Role != "ADMIN" && transaction.UserID != claims.ID {
+			return nil, auth.NotAuthorizedError
+		}
+
+		return documentURL(params.Context, DocumentInvoice, transaction.ID), nil
+	},
+}
+
+var PackingSlipURLField = &graphql.Field{
+	Type:        graphql.String,
+	Description: "A link to download the packing slip of the order as a PDF. The link expires after an hour.",
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		transaction := params.Source.(*db.Transaction)
+
+		return documentURL(params.Context, DocumentPackingSlip, transaction.ID), nil
+	},
+}
+
+// Loads a transaction with everything printed on its documents.
+func loadDocumentOrder(database *pg.DB, transactionID int) (*documents.Order, error) {
+	transaction := db.Transaction{ID: transactionID}
+	if err := database.
+		Model(&transaction).
+		Relation("Addresses").
+		Relation("Addresses.BillingAddress").
+		Relation("Addresses.ShippingAddress").
+		WherePK().
+		Select(); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not find transaction.",
+			InternalError: err,
+		}
+	}
+
+	lineItems := []*db.TransactionLineItem{}
+	if err := database.
+		Model(&lineItems).
+		Relation("ProductVariant").
+		Relation("ProductVariant.Product").
+		Where("transaction_line_item.transaction_id = ?", transactionID).
+		OrderExpr("transaction_line_item.id ASC").
+		Select(); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not load transaction line items.",
+			InternalError: err,
+		}
+	}
+
+	variantIDs := make([]int, len(lineItems))
+	for index, lineItem := range lineItems {
+		variantIDs[index] = lineItem.ProductVariantID
+	}
+
+	options := []*db.ProductVariantOption{}
+	if len(variantIDs) > 0 {
+		if err := database.
+			Model(&options).
+			Relation("ProductOptionValue").
+			Relation("ProductOptionValue.ProductOption").
+			WhereIn("product_variant_option.product_variant_id IN (?)", variantIDs).
+			Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not load the selected options of the line items.",
+				InternalError: err,
+			}
+		}
+	}
+
+	selectedOptions := map[int][]string{}
+	for _, option := range options {
+		if option.ProductOptionValue == nil {
+			continue
+		}
+
+		selected := option.ProductOptionValue.Value
+		if option.ProductOptionValue.ProductOption != nil {
+			selected = option.ProductOptionValue.ProductOption.Label + ": " + selected
+		}
+		selectedOptions[option.ProductVariantID] = append(selectedOptions[option.ProductVariantID], selected)
+	}
+
+	order := &documents.Order{
+		ID:        transaction.ID,
+		PlacedAt:  transaction.CreatedAt,
+		LineItems: make([]*documents.LineItem, len(lineItems)),
+		Subtotal:  transaction.Subtotal,
+		Taxes:     transaction.Taxes,
+		Shipping:  transaction.Shipping,
+		Total:     transaction.Total,
+	}
+
+	if transaction.Addresses != nil {
+		order.BillingAddress = transaction.Addresses.BillingAddress
+		order.ShippingAddress = transaction.Addresses.ShippingAddress
+	}
+
+	for index, lineItem := range lineItems {
+		name := "Product variant #" + strconv.Itoa(lineItem.ProductVariantID)
+		if variant := lineItem.ProductVariant; variant != nil {
+			if variant.Product != nil {
+				name = variant.Product.Name
+				if variant.Name != "" && variant.Name != variant.Product.Name {
+					name += " - " + variant.Name
+				}
+			} else if variant.Name != "" {
+				name = variant.Name
+			}
+		}
+
+		order.LineItems[index] = &documents.LineItem{
+			Name:     name,
+			Options:  selectedOptions[lineItem.ProductVariantID],
+			Quantity: lineItem.Quantity,
+			Price:    lineItem.Price,
+			Tax:      lineItem.Tax,
+		}
+	}
+
+	return order, nil
+}
+
+// Serves the PDF documents of a transaction from the signed links of InvoiceURLField and
+// PackingSlipURLField.
+func HandleDocument(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	database := ctx.Value("database").(*pg.DB)
+	signer := ctx.Value("auth").(auth.Signer)
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	document := path.Base(r.URL.Path)
+	if document != DocumentInvoice && document != DocumentPackingSlip {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	transactionID, err := strconv.Atoi(query.Get("transaction"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	message := fmt.Sprintf("%s:%d:%d", document, transactionID, expires)
+	if !signer.VerifySignature(message, query.Get("signature")) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if time.Now().Unix() > expires {
+		http.Error(w, "The link has expired.", http.StatusForbidden)
+		return
+	}
+
+	order, err := loadDocumentOrder(database, transactionID)
+	if err != nil {
+		fmt.Println("Failed to load transaction for document.")
+		fmt.Println(err)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var pdf []byte
+	if document == DocumentInvoice {
+		pdf, err = documents.NewInvoice(order)
+	} else {
+		pdf, err = documents.NewPackingSlip(order)
+	}
+	if err != nil {
+		fmt.Println("Failed to create document.")
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s-%d.pdf\"", document, transactionID))
+	w.Write(pdf)
+}
@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-pg/pg/v9"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+// The longest idempotency key a client can send.
+const maxIdempotencyKeyLength = 255
+
+var InvalidIdempotencyKeyError = fmt.Errorf("The idempotency key must be at most 255 characters.")
+var IdempotencyKeyReusedError = fmt.Errorf("The idempotency key was already used for a different checkout.")
+var CheckoutInProgressError = fmt.Errorf("The checkout with this idempotency key is still in progress.")
+
+// Hashes who is checking out and the arguments of the checkout. The payment nonce and the key are
+// left out, as a retry may tokenize the same payment method again.
+func checkoutFingerprint(userID int, args map[string]interface{}) (string, error) {
+	payload := map[string]interface{}{}
+	for name, value := range args {
+		if name != "braintreeNonce" && name != "idempotencyKey" {
+			payload[name] = value
+		}
+	}
+
+	encoded, err := json.Marshal(map[string]interface{}{
+		"userId": userID,
+		"args":   payload,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Finds the transaction an earlier checkout created with the idempotency key. Returns nil when no
+// transaction has the key, which includes checkouts whose order was abandoned after a declined
+// payment, IdempotencyKeyReusedError when the key was used for a different checkout, and
+// CheckoutInProgressError while the payment of the transaction is still pending.
+func findIdempotentTransaction(database *pg.DB, key string, fingerprint string) (*db.Transaction, error) {
+	transaction := db.Transaction{}
+	if err := database.
+		Model(&transaction).
+		Where("transaction.idempotency_key = ?", key).
+		Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, &core.WrappedError{
+			Message:       "Could not look up the idempotency key.",
+			InternalError: err,
+		}
+	}
+
+	if transaction.IdempotencyFingerprint != fingerprint {
+		return nil, IdempotencyKeyReusedError
+	}
+	if isPaymentPending(&transaction) {
+		return nil, CheckoutInProgressError
+	}
+
+	return &transaction, nil
+}
+
+// Whether an insert failed because another checkout took the idempotency key first.
+func isIdempotencyKeyTaken(err error) bool {
+	pgErr, ok := err.(pg.Error)
+	return ok && pgErr.Field('C') == "23505" && pgErr.Field('n') == "transactions_idempotency_key_idx"
+}
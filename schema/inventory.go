@@ -0,0 +1,439 @@
+package schema
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+const (
+	InventoryReasonSale       = "SALE"
+	InventoryReasonRelease    = "RELEASE"
+	InventoryReasonRestock    = "RESTOCK"
+	InventoryReasonReturn     = "RETURN"
+	InventoryReasonDamage     = "DAMAGE"
+	InventoryReasonCorrection = "CORRECTION"
+)
+
+var NegativeStockError = fmt.Errorf("Stock can not be adjusted below zero.")
+var InvalidAdjustmentError = fmt.Errorf("Adjustment quantity must not be zero.")
+var SystemReasonError = fmt.Errorf("SALE and RELEASE adjustments can only be made by checkout.")
+
+var InventoryAdjustmentReasonEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "InventoryAdjustmentReason",
+	Values: graphql.EnumValueConfigMap{
+		InventoryReasonSale: &graphql.EnumValueConfig{
+			Value:       InventoryReasonSale,
+			Description: "Units taken by a transaction line item.",
+		},
+		InventoryReasonRelease: &graphql.EnumValueConfig{
+			Value:       InventoryReasonRelease,
+			Description: "Units put back from a failed or cancelled transaction.",
+		},
+		InventoryReasonRestock: &graphql.EnumValueConfig{
+			Value:       InventoryReasonRestock,
+			Description: "New units received.",
+		},
+		InventoryReasonReturn: &graphql.EnumValueConfig{
+			Value:       InventoryReasonReturn,
+			Description: "Units returned by a customer.",
+		},
+		InventoryReasonDamage: &graphql.EnumValueConfig{
+			Value:       InventoryReasonDamage,
+			Description: "Units that were damaged or lost.",
+		},
+		InventoryReasonCorrection: &graphql.EnumValueConfig{
+			Value:       InventoryReasonCorrection,
+			Description: "A manual correction or stock count.",
+		},
+	},
+})
+
+var InventoryAdjustmentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "InventoryAdjustment",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+		},
+		"quantity": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "The change in stock. Negative values take units out of stock.",
+		},
+		"reason": &graphql.Field{
+			Type: graphql.NewNonNull(InventoryAdjustmentReasonEnum),
+		},
+		"note": &graphql.Field{
+			Type: graphql.String,
+		},
+		"transactionLineItemId": &graphql.Field{
+			Type: graphql.Int,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				adjustment := params.Source.(*db.InventoryAdjustment)
+
+				if adjustment.TransactionLineItemID == 0 {
+					return nil, nil
+				}
+
+				return adjustment.TransactionLineItemID, nil
+			},
+		},
+		"madeBy": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The email of the user that made the adjustment.",
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				userLoader := params.Context.Value("user").(*dataloader.Loader)
+
+				adjustment := params.Source.(*db.InventoryAdjustment)
+
+				if adjustment.UserID == 0 {
+					return nil, nil
+				}
+
+				thunk := userLoader.Load(params.Context, dataloaders.IntKey(adjustment.UserID))
+
+				return func() (interface{}, error) {
+					result, err := thunk()
+
+					if err != nil || result == nil {
+						return nil, err
+					}
+
+					return result.(*db.User).Email, nil
+				}, nil
+			},
+		},
+	},
+})
+
+var StockHistoryField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(InventoryAdjustmentType)),
+	Description: "The inventory adjustments for the variant, newest first. Only visible to admins.",
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		stockHistory := params.Context.Value("productVariantStockHistory").(*dataloader.Loader)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		variant := params.Source.(*db.ProductVariant)
+
+		thunk := stockHistory.Load(params.Context, dataloaders.IntKey(variant.ID))
+
+		return func() (interface{}, error) {
+			return thunk()
+		}, nil
+	},
+}
+
+// Applies the adjustment to the variant's stock and appends it to the ledger. The variant row
+// is locked for the duration of the transaction so concurrent checkouts can't both take the
+// last unit.
+func adjustStock(tx *pg.Tx, adjustment *db.InventoryAdjustment) error {
+	if adjustment.Quantity == 0 {
+		return InvalidAdjustmentError
+	}
+
+	variant := db.ProductVariant{}
+	if err := tx.
+		Model(&variant).
+		Column("product_variant.id").
+		Column("product_variant.stock").
+		Where("product_variant.id = ?", adjustment.ProductVariantID).
+		For("UPDATE").
+		Select(); err != nil {
+		return &core.WrappedError{
+			Message:       "Could not find product variant to adjust stock for.",
+			InternalError: err,
+		}
+	}
+
+	if variant.Stock+adjustment.Quantity < 0 {
+		if adjustment.Reason == InventoryReasonSale {
+			return dataloaders.OutOfStockError
+		}
+
+		return NegativeStockError
+	}
+
+	if _, err := tx.
+		Model(&variant).
+		Set("stock = stock + ?", adjustment.Quantity).
+		WherePK().
+		Update(); err != nil {
+		return &core.WrappedError{
+			Message:       "Could not adjust stock.",
+			InternalError: err,
+		}
+	}
+
+	adjustment.CreatedAt = time.Now()
+	if err := tx.Insert(adjustment); err != nil {
+		return &core.WrappedError{
+			Message:       "Could not record inventory adjustment.",
+			InternalError: err,
+		}
+	}
+
+	return nil
+}
+
+// Adjusts the variant's stock to match a counted value, recording the difference as a correction.
+// Returns nil if the count already matches.
+func countStock(tx *pg.Tx, variantID int, count int, userID int, note string) (*db.InventoryAdjustment, error) {
+	if count < 0 {
+		return nil, NegativeStockError
+	}
+
+	variant := db.ProductVariant{}
+	if err := tx.
+		Model(&variant).
+		Column("product_variant.id").
+		Column("product_variant.stock").
+		Where("product_variant.id = ?", variantID).
+		For("UPDATE").
+		Select(); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not find product variant `" + strconv.Itoa(variantID) + "` to count stock for.",
+			InternalError: err,
+		}
+	}
+
+	if variant.Stock == count {
+		return nil, nil
+	}
+
+	adjustment := db.InventoryAdjustment{
+		ProductVariantID: variantID,
+		Quantity:         count - variant.Stock,
+		Reason:           InventoryReasonCorrection,
+		Note:             note,
+		UserID:           userID,
+	}
+	if err := adjustStock(tx, &adjustment); err != nil {
+		return nil, err
+	}
+
+	return &adjustment, nil
+}
+
+// Takes the line item's quantity out of stock and records the sale against it.
+func reserveStock(tx *pg.Tx, lineItem *db.TransactionLineItem, userID int) error {
+	return adjustStock(tx, &db.InventoryAdjustment{
+		ProductVariantID:      lineItem.ProductVariantID,
+		Quantity:              -lineItem.Quantity,
+		Reason:                InventoryReasonSale,
+		UserID:                userID,
+		TransactionLineItemID: lineItem.ID,
+	})
+}
+
+// Puts the line item's reserved stock back on the shelf, less the units refunds already returned to
+// stock.
+func releaseStock(tx *pg.Tx, lineItem *db.TransactionLineItem, userID int, note string) error {
+	returned := 0
+	if err := tx.
+		Model((*db.InventoryAdjustment)(nil)).
+		ColumnExpr("coalesce(sum(inventory_adjustment.quantity), 0)").
+		Where("inventory_adjustment.transaction_line_item_id = ?", lineItem.ID).
+		Where("inventory_adjustment.reason = ?", InventoryReasonReturn).
+		Select(pg.Scan(&returned)); err != nil {
+		return &core.WrappedError{
+			Message:       "Could not get the returned stock of the line item.",
+			InternalError: err,
+		}
+	}
+
+	if lineItem.Quantity-returned <= 0 {
+		return nil
+	}
+
+	return adjustStock(tx, &db.InventoryAdjustment{
+		ProductVariantID:      lineItem.ProductVariantID,
+		Quantity:              lineItem.Quantity - returned,
+		Reason:                InventoryReasonRelease,
+		Note:                  note,
+		UserID:                userID,
+		TransactionLineItemID: lineItem.ID,
+	})
+}
+
+var AdjustInventoryField = &graphql.Field{
+	Type:        InventoryAdjustmentType,
+	Description: "Post an inventory adjustment for a product variant.",
+	Args: graphql.FieldConfigArgument{
+		"productVariantId": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"quantity": &graphql.ArgumentConfig{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "The change in stock. Use negative values to take units out of stock.",
+		},
+		"reason": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(InventoryAdjustmentReasonEnum),
+		},
+		"note": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "Why the adjustment was made.",
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		note, _ := params.Args["note"].(string)
+		adjustment := db.InventoryAdjustment{
+			ProductVariantID: params.Args["productVariantId"].(int),
+			Quantity:         params.Args["quantity"].(int),
+			Reason:           params.Args["reason"].(string),
+			Note:             strings.TrimSpace(note),
+			UserID:           claims.ID,
+		}
+
+		if adjustment.Reason == InventoryReasonSale || adjustment.Reason == InventoryReasonRelease {
+			return nil, SystemReasonError
+		}
+
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			return adjustStock(tx, &adjustment)
+		}); err != nil {
+			return nil, err
+		}
+
+		return &adjustment, nil
+	},
+}
+
+var ImportStockCountField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(InventoryAdjustmentType)),
+	Description: "Import a CSV stock count with `variant_id,count` rows. A correction is recorded for every variant whose count differs from its stock. Either every row is applied or none are.",
+	Args: graphql.FieldConfigArgument{
+		"file": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(UploadScalar),
+		},
+		"note": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "Why the count was taken.",
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		file := params.Args["file"].(*core.MultipartFile)
+		defer file.File.Close()
+
+		note, _ := params.Args["note"].(string)
+		note = strings.TrimSpace(note)
+		if note == "" {
+			note = "Stock count import."
+		}
+
+		counts, err := parseStockCount(file.File)
+		if err != nil {
+			return nil, err
+		}
+
+		adjustments := []*db.InventoryAdjustment{}
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			for _, count := range counts {
+				adjustment, err := countStock(tx, count.VariantID, count.Count, claims.ID, note)
+				if err != nil {
+					return err
+				}
+
+				if adjustment != nil {
+					adjustments = append(adjustments, adjustment)
+				}
+			}
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+
+		return adjustments, nil
+	},
+}
+
+type stockCount struct {
+	VariantID int
+	Count     int
+}
+
+// Parses `variant_id,count` rows. A header row is skipped if present.
+func parseStockCount(reader io.Reader) ([]stockCount, error) {
+	rows, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not read stock count CSV.",
+			InternalError: err,
+		}
+	}
+
+	counts := []stockCount{}
+	seen := map[int]bool{}
+	for index, row := range rows {
+		if len(row) < 2 {
+			return nil, fmt.Errorf("Row %d of the stock count must have a variant_id and a count.", index+1)
+		}
+
+		variantID, err := strconv.Atoi(strings.TrimSpace(row[0]))
+		if err != nil {
+			if index == 0 {
+				continue
+			}
+
+			return nil, fmt.Errorf("Row %d of the stock count has an invalid variant_id.", index+1)
+		}
+
+		count, err := strconv.Atoi(strings.TrimSpace(row[1]))
+		if err != nil {
+			return nil, fmt.Errorf("Row %d of the stock count has an invalid count.", index+1)
+		}
+
+		if seen[variantID] {
+			return nil, fmt.Errorf("Row %d of the stock count repeats variant %d.", index+1, variantID)
+		}
+		seen[variantID] = true
+
+		counts = append(counts, stockCount{
+			VariantID: variantID,
+			Count:     count,
+		})
+	}
+
+	return counts, nil
+}
@@ -20,6 +20,23 @@ var MeType = graphql.NewObject(graphql.ObjectConfig{
 		"email": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.String),
 		},
+		"createdAt": &graphql.Field{
+			Type:        graphql.DateTime,
+			Description: "When the account was created.",
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				return loadMeUser(params, func(user *db.User) interface{} {
+					return user.CreatedAt
+				}), nil
+			},
+		},
+		"updatedAt": &graphql.Field{
+			Type: graphql.DateTime,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				return loadMeUser(params, func(user *db.User) interface{} {
+					return user.UpdatedAt
+				}), nil
+			},
+		},
 		"addresses": &graphql.Field{
 			Type: graphql.NewList(graphql.NewNonNull(AddressType)),
 			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
@@ -51,6 +68,29 @@ var MeType = graphql.NewObject(graphql.ObjectConfig{
 	},
 })
 
+// Loads the user of the claims and resolves a value of it.
+func loadMeUser(params graphql.ResolveParams, resolve func(user *db.User) interface{}) func() (interface{}, error) {
+	userLoader := params.Context.Value("user").(*dataloader.Loader)
+
+	me := params.Source.(*auth.Claims)
+
+	thunk := userLoader.Load(params.Context, dataloaders.IntKey(me.ID))
+
+	return func() (interface{}, error) {
+		result, err := thunk()
+		if err != nil {
+			return nil, err
+		}
+
+		user, ok := result.(*db.User)
+		if !ok {
+			return nil, nil
+		}
+
+		return resolve(user), nil
+	}
+}
+
 var MeField = &graphql.Field{
 	Type:        MeType,
 	Description: "Your information.",
@@ -13,6 +13,21 @@ var MutationType = graphql.NewObject(graphql.ObjectConfig{
 
 		"createAddress": CreateAddressField,
 
+		"createOriginAddress":    CreateOriginAddressField,
+		"removeOriginAddress":    RemoveOriginAddressField,
+		"createShippingBox":      CreateShippingBoxField,
+		"updateShippingBox":      UpdateShippingBoxField,
+		"removeShippingBox":      RemoveShippingBoxField,
+		"createShippingZone":     CreateShippingZoneField,
+		"removeShippingZone":     RemoveShippingZoneField,
+		"createShippingZoneRate": CreateShippingZoneRateField,
+		"removeShippingZoneRate": RemoveShippingZoneRateField,
+		"createTaxRule":          CreateTaxRuleField,
+		"updateTaxRule":          UpdateTaxRuleField,
+		"removeTaxRule":          RemoveTaxRuleField,
+		"commitTransactionTaxes": CommitTransactionTaxesField,
+		"refundTransaction":      RefundTransactionField,
+
 		"createProductDraft": CreateProductDraftField,
 		"updateProduct":      UpdateProductField,
 		"publishProduct":     PublishProductField,
@@ -29,7 +44,19 @@ var MutationType = graphql.NewObject(graphql.ObjectConfig{
 		"removeProductVariantImage": RemoveProductVariantImageField,
 		"createProductPermutations": CreateProductPermutationsField,
 
+		"adjustInventory":  AdjustInventoryField,
+		"importStockCount": ImportStockCountField,
+
+		"addCartItem":    AddCartItemField,
+		"updateCartItem": UpdateCartItemField,
+		"removeCartItem": RemoveCartItemField,
+		"emptyCart":      EmptyCartField,
+
+		"sendAbandonedCartReminders": SendAbandonedCartRemindersField,
+
+		"createCheckoutQuote":        CreateCheckoutQuoteField,
 		"submitBraintreeTransaction": SubmitBraintreeTransactionField,
+		"reconcilePendingPayments":   ReconcilePendingPaymentsField,
 
 		"purchaseShippoLabel": PurchaseShippoLabelField,
 	},
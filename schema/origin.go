@@ -0,0 +1,168 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+var NotAnOriginError = fmt.Errorf("The provided address is not an origin address.")
+
+// Makes sure the address exists and is an origin address.
+func validateOrigin(database *pg.DB, addressID int) error {
+	exists, err := database.
+		Model(&db.Address{}).
+		Where("address.id = ?", addressID).
+		Where("address.origin IS TRUE").
+		Exists()
+
+	if err != nil {
+		return &core.WrappedError{
+			Message:       "Could not find origin address.",
+			InternalError: err,
+		}
+	}
+
+	if !exists {
+		return NotAnOriginError
+	}
+
+	return nil
+}
+
+var OriginAddressesField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(AddressType)),
+	Description: "The store addresses product variants can ship from. Variants without an origin ship from the first one.",
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		origins := []*db.Address{}
+		if err := database.
+			Model(&origins).
+			Where("address.origin IS TRUE").
+			OrderExpr("address.id ASC").
+			Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not get origin addresses.",
+				InternalError: err,
+			}
+		}
+
+		return origins, nil
+	},
+}
+
+var CreateOriginAddressField = &graphql.Field{
+	Type:        AddressType,
+	Description: "Create a store address product variants can ship from.",
+	Args: graphql.FieldConfigArgument{
+		"address": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(AddressInputSchema),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+		addressValidator := params.Context.Value("addressValidator").(services.AddressValidator)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		address := db.Address{}
+		if err := ConvertObject(params.Args["address"], &address); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not convert address argument.",
+				InternalError: err,
+			}
+		}
+
+		address.Origin = true
+
+		_, err := addressValidator.ValidateAddress(params.Context, address)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := database.Insert(&address); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not create origin address.",
+				InternalError: err,
+			}
+		}
+
+		return &address, nil
+	},
+}
+
+var RemoveOriginAddressField = &graphql.Field{
+	Type:        AddressType,
+	Description: "Remove a store origin address. Variants that shipped from it fall back to the default origin.",
+	Args: graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		id := params.Args["id"].(int)
+
+		toDelete := db.Address{}
+		if err := database.
+			Model(&toDelete).
+			Where("address.id = ?", id).
+			Where("address.origin IS TRUE").
+			Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not retrieve origin address to remove.",
+				InternalError: err,
+			}
+		}
+
+		if _, err := database.
+			Model(&db.ProductVariant{}).
+			Set("ships_from_id = NULL").
+			Where("ships_from_id = ?", id).
+			Update(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not unassign origin address from product variants.",
+				InternalError: err,
+			}
+		}
+
+		if err := database.Delete(&toDelete); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not remove origin address.",
+				InternalError: err,
+			}
+		}
+
+		return &toDelete, nil
+	},
+}
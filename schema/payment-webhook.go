@@ -0,0 +1,198 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+// Records a refund made outside of the store, such as from the gateway's dashboard. Refunds the
+// store made are recorded under the same gateway ID, so they are not recorded twice. Returns an
+// empty status when the refund was already recorded.
+func recordGatewayRefund(database *pg.DB, transaction *db.Transaction, event *services.PaymentEvent) (string, error) {
+	refunded := 0
+	inserted := 0
+	if err := database.RunInTransaction(func(tx *pg.Tx) error {
+		// Waits for a refund the store is making to be recorded.
+		if err := tx.Model(transaction).WherePK().For("UPDATE").Select(); err != nil {
+			return err
+		}
+
+		result, err := tx.
+			Model(&db.TransactionRefund{
+				CreatedAt:     event.Timestamp,
+				TransactionID: transaction.ID,
+				GatewayID:     event.RefundID,
+				Amount:        event.Amount,
+				Reason:        "Refunded with the payment gateway.",
+				LineItems:     []*db.RefundLineItem{},
+			}).
+			OnConflict("(gateway_id) DO NOTHING").
+			Insert()
+		if err != nil {
+			return err
+		}
+		inserted = result.RowsAffected()
+
+		refunds := []*db.TransactionRefund{}
+		if err := tx.
+			Model(&refunds).
+			Where("transaction_id = ?", transaction.ID).
+			Select(); err != nil {
+			return err
+		}
+
+		for _, refund := range refunds {
+			refunded += refund.Amount
+		}
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	if inserted == 0 {
+		return "", nil
+	}
+
+	if refunded >= transaction.Total {
+		return TransactionStatusRefunded, nil
+	}
+
+	return TransactionStatusPartiallyRefunded, nil
+}
+
+func handlePaymentEvent(ctx context.Context, event *services.PaymentEvent) error {
+	database := ctx.Value("database").(*pg.DB)
+
+	if event.Kind == "" || event.Kind == services.PaymentEventCheck {
+		return nil
+	}
+
+	// Statuses and refunds need a time, and not every gateway sends one.
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	transaction := db.Transaction{}
+	if err := database.
+		Model(&transaction).
+		Where("braintree_id = ?", event.TransactionID).
+		Select(); err != nil {
+		if err == pg.ErrNoRows {
+			fmt.Println("Received payment event for unknown transaction " + event.TransactionID + ".")
+			return nil
+		}
+
+		return err
+	}
+
+	status := ""
+	switch event.Kind {
+	case services.PaymentEventSettled:
+		status = TransactionStatusSettled
+		updatePaymentStatus(database, &transaction, services.PaymentStatusSettled)
+	case services.PaymentEventSettlementDeclined:
+		status = TransactionStatusSettlementDeclined
+	case services.PaymentEventRefunded:
+		refundStatus, err := recordGatewayRefund(database, &transaction, event)
+		if err != nil {
+			return err
+		}
+		if refundStatus == "" {
+			return nil
+		}
+
+		// Every new refund is recorded, even when the one before it was partial too.
+		return appendTransactionStatus(database, &db.TransactionStatus{
+			CreatedAt:     event.Timestamp,
+			TransactionID: transaction.ID,
+			Status:        refundStatus,
+		})
+	case services.PaymentEventDisputeOpened:
+		status = TransactionStatusDisputed
+		transaction.Disputed = true
+		if _, err := database.
+			Model(&transaction).
+			Column("disputed", "updated_at").
+			WherePK().
+			Update(); err != nil {
+			return err
+		}
+	case services.PaymentEventDisputeWon:
+		status = TransactionStatusDisputeWon
+	case services.PaymentEventDisputeLost:
+		status = TransactionStatusDisputeLost
+	default:
+		return nil
+	}
+
+	// Gateways deliver webhooks again when they are not acknowledged, so a status is only recorded
+	// once in a row.
+	latest := db.TransactionStatus{}
+	err := database.
+		Model(&latest).
+		Where("transaction_id = ?", transaction.ID).
+		OrderExpr("id DESC").
+		Limit(1).
+		Select()
+	if err != nil && err != pg.ErrNoRows {
+		return err
+	}
+	if err == nil && latest.Status == status {
+		return nil
+	}
+
+	return appendTransactionStatus(database, &db.TransactionStatus{
+		CreatedAt:     event.Timestamp,
+		TransactionID: transaction.ID,
+		Status:        status,
+	})
+}
+
+// Receives the payment gateway's webhooks. Braintree verifies the URL with a GET request that has a
+// bt_challenge, and posts signed events to it.
+func HandlePaymentWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	paymentGateway := ctx.Value("paymentGateway").(services.PaymentGateway)
+
+	if challenge := r.URL.Query().Get("bt_challenge"); r.Method == http.MethodGet && challenge != "" {
+		response, err := paymentGateway.VerifyWebhook(challenge)
+		if err != nil {
+			fmt.Println("Failed to verify payment webhook.")
+			fmt.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Write([]byte(response))
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	event, err := paymentGateway.ParseWebhook(r)
+	if err != nil {
+		fmt.Println("Rejected payment webhook.")
+		fmt.Println(err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err := handlePaymentEvent(ctx, event); err != nil {
+		fmt.Println("Failed to handle payment webhook.")
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,189 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+// The payment status of an order that was saved at checkout before its payment was made. The order
+// stays pending until the sale is recorded against it, or it is deleted because it was never paid.
+const PaymentStatusPending = "pending"
+
+var PaymentPendingError = fmt.Errorf("The payment of the transaction has not been confirmed yet.")
+
+func isPaymentPending(transaction *db.Transaction) bool {
+	return transaction.PaymentStatus == PaymentStatusPending
+}
+
+// Records the sale made for a pending order and moves the order on. Orders that are no longer
+// pending, because checkout or a reconciliation already completed them, are left as they are.
+func completePendingOrder(ctx context.Context, transaction *db.Transaction, payment *services.PaymentTransaction) error {
+	database := ctx.Value("database").(*pg.DB)
+
+	transaction.BraintreeID = payment.ID
+	transaction.PaymentStatus = payment.Status
+	result, err := database.
+		Model(transaction).
+		Column("braintree_id", "payment_status", "updated_at").
+		WherePK().
+		Where("payment_status = ?", PaymentStatusPending).
+		Update()
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return nil
+	}
+
+	// Authorized payments commit their taxes once they are captured.
+	if isPaymentAuthorized(transaction) {
+		recordTransactionStatus(database, transaction.ID, TransactionStatusAuthorized)
+	} else {
+		commitTransactionTaxes(ctx, transaction)
+	}
+
+	return nil
+}
+
+// Deletes a pending order that was never paid for, along with everything created with it, and puts
+// its stock back. Orders that are no longer pending are left alone.
+func abandonPendingOrder(database *pg.DB, transaction *db.Transaction, note string) error {
+	return database.RunInTransaction(func(tx *pg.Tx) error {
+		pending := db.Transaction{ID: transaction.ID}
+		if err := tx.
+			Model(&pending).
+			Column("transaction.id").
+			WherePK().
+			Where("transaction.payment_status = ?", PaymentStatusPending).
+			For("UPDATE").
+			Select(); err != nil {
+			if err == pg.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+
+		lineItems := []*db.TransactionLineItem{}
+		if err := tx.
+			Model(&lineItems).
+			Where("transaction_line_item.transaction_id = ?", transaction.ID).
+			Select(); err != nil {
+			return err
+		}
+		for _, lineItem := range lineItems {
+			if err := releaseStock(tx, lineItem, transaction.UserID, note); err != nil {
+				return err
+			}
+		}
+
+		shipmentIDs := tx.
+			Model((*db.TransactionShipment)(nil)).
+			Column("transaction_shipment.id").
+			Where("transaction_shipment.transaction_id = ?", transaction.ID)
+		if _, err := tx.
+			Model((*db.TransactionParcel)(nil)).
+			Where("transaction_parcel.transaction_shipment_id IN (?)", shipmentIDs).
+			Delete(); err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{
+			(*db.TransactionLineItem)(nil),
+			(*db.TransactionShipment)(nil),
+			(*db.TransactionAddressInfo)(nil),
+			(*db.TransactionStatus)(nil),
+		} {
+			if _, err := tx.Model(model).Where("transaction_id = ?", transaction.ID).Delete(); err != nil {
+				return err
+			}
+		}
+
+		// The quote the order was placed with can be used again, for example with another card.
+		if _, err := tx.
+			Model((*db.CheckoutQuote)(nil)).
+			Set("transaction_id = NULL").
+			Where("transaction_id = ?", transaction.ID).
+			Update(); err != nil {
+			return err
+		}
+
+		_, err := tx.Model(&pending).WherePK().Delete()
+		return err
+	})
+}
+
+var ReconcilePendingPaymentsField = &graphql.Field{
+	Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(TransactionType))),
+	Description: "Match the orders left pending by an interrupted checkout with the payment gateway. Orders that were charged are completed and returned, the others are deleted and their stock put back.",
+	Args: graphql.FieldConfigArgument{
+		"olderThanMinutes": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: 15,
+			Description:  "Only orders placed at least this long ago are reconciled, so checkouts still in progress are left alone.",
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+		paymentGateway := params.Context.Value("paymentGateway").(services.PaymentGateway)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		olderThan := time.Duration(params.Args["olderThanMinutes"].(int)) * time.Minute
+
+		transactions := []*db.Transaction{}
+		if err := database.
+			Model(&transactions).
+			Where("transaction.payment_status = ?", PaymentStatusPending).
+			Where("transaction.created_at < ?", time.Now().Add(-olderThan)).
+			OrderExpr("transaction.id ASC").
+			Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not get pending transactions.",
+				InternalError: err,
+			}
+		}
+
+		completed := []*db.Transaction{}
+		for _, transaction := range transactions {
+			payment, err := paymentGateway.FindByOrderID(params.Context, strconv.Itoa(transaction.ID))
+			if err != nil {
+				fmt.Println("Failed to find payment for pending transaction.")
+				fmt.Println(err)
+				continue
+			}
+
+			if payment == nil {
+				if err := abandonPendingOrder(database, transaction, "Payment never completed."); err != nil {
+					fmt.Println("Failed to delete unpaid pending transaction.")
+					fmt.Println(err)
+				}
+				continue
+			}
+
+			if err := completePendingOrder(params.Context, transaction, payment); err != nil {
+				fmt.Println("Failed to complete pending transaction.")
+				fmt.Println(err)
+				continue
+			}
+			completed = append(completed, transaction)
+		}
+
+		return completed, nil
+	},
+}
@@ -117,48 +117,37 @@ var CreateProductOptionField = &graphql.Field{
 			}
 		}
 
+		for index, inputValue := range inputValues {
+			inputValues[index].Value = strings.TrimSpace(inputValue.Value)
+			if inputValues[index].Value == "" {
+				return nil, fmt.Errorf("Values must not be empty.")
+			}
+		}
+
 		option := db.ProductOption{
 			Label:     label,
 			ProductID: productID,
 		}
 
-		if err := database.Insert(&option); err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not create product draft.",
-				InternalError: err,
-			}
-		}
-
-		var err error
-
-		for index, inputValue := range inputValues {
-			inputValues[index].Value = strings.TrimSpace(inputValue.Value)
-			if inputValues[index].Value == "" {
-				err = fmt.Errorf("Values must not be empty.")
-				break
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			if err := tx.Insert(&option); err != nil {
+				return err
 			}
 
-			inputValues[index].ProductOptionID = option.ID
+			for index := range inputValues {
+				inputValues[index].ProductOptionID = option.ID
 
-			if err := database.Insert(&inputValues[index]); err != nil {
-				err = &core.WrappedError{
-					Message:       "Could not create product draft.",
-					InternalError: err,
+				if err := tx.Insert(&inputValues[index]); err != nil {
+					return err
 				}
-				break
 			}
-		}
-
-		if err != nil {
-			database.Delete(&option)
-			database.ForceDelete(&option)
 
-			for _, value := range inputValues {
-				database.Delete(&value)
-				database.ForceDelete(&value)
+			return nil
+		}); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not create product option.",
+				InternalError: err,
 			}
-
-			return nil, err
 		}
 
 		return &option, nil
@@ -61,6 +61,54 @@ var ProductVariantType = graphql.NewObject(
 			"weight": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Float),
 			},
+			"available": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.Boolean),
+				Description: "If the variant has stock available for purchase.",
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					variant := params.Source.(*db.ProductVariant)
+
+					return variant.Stock > 0, nil
+				},
+			},
+			"stock": &graphql.Field{
+				Type:        graphql.Int,
+				Description: "The number of units available for purchase. Only visible to admins.",
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					claims := params.Context.Value("claims").(*auth.Claims)
+
+					variant := params.Source.(*db.ProductVariant)
+
+					if claims == nil || claims.Role != "ADMIN" {
+						return nil, nil
+					}
+
+					return variant.Stock, nil
+				},
+			},
+			"shipsFrom": &graphql.Field{
+				Type:        AddressType,
+				Description: "The origin address the variant ships from. If none is set, the default origin is used.",
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					addressLoader := params.Context.Value("address").(*dataloader.Loader)
+
+					variant := params.Source.(*db.ProductVariant)
+
+					if variant.ShipsFromID == 0 {
+						return nil, nil
+					}
+
+					thunk := addressLoader.Load(params.Context, dataloaders.IntKey(variant.ShipsFromID))
+
+					return func() (interface{}, error) {
+						return thunk()
+					}, nil
+				},
+			},
+			"taxCode": &graphql.Field{
+				Type:        graphql.String,
+				Description: "Overrides the product's tax code. If none is set, the product's tax code is used.",
+			},
+			"stockHistory": StockHistoryField,
 			"selectedOptions": &graphql.Field{
 				Type: graphql.NewList(ProductOptionValueType),
 				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
@@ -224,6 +272,18 @@ var CreateProductVariantInputSchema = graphql.NewInputObject(graphql.InputObject
 			Type:        graphql.NewNonNull(graphql.Float),
 			Description: "The weight in ounces.",
 		},
+		"stock": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "The number of units available for purchase.",
+		},
+		"shipsFromId": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "The origin address the variant ships from.",
+		},
+		"taxCode": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "Overrides the product's tax code.",
+		},
 	},
 })
 
@@ -262,49 +322,61 @@ var CreateProductVariantField = &graphql.Field{
 			}
 		}
 		input.ProductID = params.Args["productId"].(int)
+		input.TaxCode = strings.TrimSpace(input.TaxCode)
+		initialStock := input.Stock
+		input.Stock = 0
 
-		selectedProductOptionValues := []int{}
-		if err := ConvertObject(params.Args["selectedProductOptionValues"], &selectedProductOptionValues); err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not decode input.",
-				InternalError: err,
+		if input.ShipsFromID != 0 {
+			if err := validateOrigin(database, input.ShipsFromID); err != nil {
+				return nil, err
 			}
 		}
 
-		if err := database.Insert(&input); err != nil {
+		selectedProductOptionValues := []int{}
+		if err := ConvertObject(params.Args["selectedProductOptionValues"], &selectedProductOptionValues); err != nil {
 			return nil, &core.WrappedError{
-				Message:       "Could not create product variant.",
+				Message:       "Could not decode input.",
 				InternalError: err,
 			}
 		}
 
-		inserted := []db.ProductVariantOption{}
-		var err error = nil
-		for _, selectedOptionValueId := range selectedProductOptionValues {
-			selected := db.ProductVariantOption{
-				ProductOptionValueID: selectedOptionValueId,
-				ProductVariantID:     input.ID,
-				ProductID:            input.ProductID,
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			if err := tx.Insert(&input); err != nil {
+				return &core.WrappedError{
+					Message:       "Could not create product variant.",
+					InternalError: err,
+				}
 			}
 
-			err = database.Insert(&selected)
-			if err != nil {
-				break
+			for _, selectedOptionValueId := range selectedProductOptionValues {
+				if err := tx.Insert(&db.ProductVariantOption{
+					ProductOptionValueID: selectedOptionValueId,
+					ProductVariantID:     input.ID,
+					ProductID:            input.ProductID,
+				}); err != nil {
+					return &core.WrappedError{
+						Message:       "Could not create product variant.",
+						InternalError: err,
+					}
+				}
 			}
 
-			inserted = append(inserted, selected)
-		}
-
-		if err != nil {
-			for _, toDelete := range inserted {
-				database.Delete(&toDelete)      // TODO: Log error
-				database.ForceDelete(&toDelete) // TODO: Log error
+			if initialStock != 0 {
+				if err := adjustStock(tx, &db.InventoryAdjustment{
+					ProductVariantID: input.ID,
+					Quantity:         initialStock,
+					Reason:           InventoryReasonRestock,
+					Note:             "Initial stock.",
+					UserID:           claims.ID,
+				}); err != nil {
+					return err
+				}
+				input.Stock = initialStock
 			}
 
-			return nil, &core.WrappedError{
-				Message:       "Could not create product variant.",
-				InternalError: err,
-			}
+			return nil
+		}); err != nil {
+			return nil, err
 		}
 
 		return &input, nil
@@ -338,6 +410,18 @@ var UpdateProductVariantInputSchema = graphql.NewInputObject(graphql.InputObject
 			Type:        graphql.Float,
 			Description: "The weight in ounces.",
 		},
+		"stock": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "The number of units available for purchase.",
+		},
+		"shipsFromId": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "The origin address the variant ships from.",
+		},
+		"taxCode": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "Overrides the product's tax code.",
+		},
 	},
 })
 
@@ -373,6 +457,9 @@ var UpdateProductVariantField = &graphql.Field{
 		width := OptionalFloat(input, "width")
 		height := OptionalFloat(input, "height")
 		weight := OptionalFloat(input, "weight")
+		stock := OptionalInt(input, "stock")
+		shipsFromID := OptionalInt(input, "shipsFromId")
+		taxCode := OptionalString(input, "taxCode")
 
 		result := db.ProductVariant{ID: id}
 		if err := database.Select(&result); err != nil {
@@ -402,12 +489,39 @@ var UpdateProductVariantField = &graphql.Field{
 		if weight != nil {
 			result.Weight = *weight
 		}
+		if taxCode != nil {
+			result.TaxCode = strings.TrimSpace(*taxCode)
+		}
 
-		if err := database.Update(&result); err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not update product variant.",
-				InternalError: err,
+		if shipsFromID != nil {
+			if err := validateOrigin(database, *shipsFromID); err != nil {
+				return nil, err
+			}
+			result.ShipsFromID = *shipsFromID
+		}
+
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			if _, err := tx.
+				Model(&result).
+				ExcludeColumn("stock").
+				WherePK().
+				Update(); err != nil {
+				return &core.WrappedError{
+					Message:       "Could not update product variant.",
+					InternalError: err,
+				}
+			}
+
+			if stock != nil {
+				if _, err := countStock(tx, id, *stock, claims.ID, "Updated with the product variant."); err != nil {
+					return err
+				}
+				result.Stock = *stock
 			}
+
+			return nil
+		}); err != nil {
+			return nil, err
 		}
 
 		return &result, nil
@@ -437,6 +551,14 @@ var CreateProductPermutationsInputSchema = graphql.NewInputObject(graphql.InputO
 			Type:        graphql.NewNonNull(graphql.Float),
 			Description: "The weight in ounces.",
 		},
+		"stock": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "The number of units available for purchase of each variant.",
+		},
+		"shipsFromId": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "The origin address the variant ships from.",
+		},
 	},
 })
 
@@ -473,13 +595,9 @@ var CreateProductPermutationsField = &graphql.Field{
 		}
 		input.ProductID = params.Args["productId"].(int)
 
-		if exists, err := database.
-			Model(&db.ProductVariant{}).
-			Where("product_id = ?", input.ProductID).
-			Exists(); exists || err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Product already contains product vairants.",
-				InternalError: err,
+		if input.ShipsFromID != 0 {
+			if err := validateOrigin(database, input.ShipsFromID); err != nil {
+				return nil, err
 			}
 		}
 
@@ -509,52 +627,65 @@ var CreateProductPermutationsField = &graphql.Field{
 		permutations := utilities.Permutate(optionIDs)
 
 		createdVariants := []*db.ProductVariant{}
-		var err error
-		for permutaitonIndex, permutation := range permutations {
-			variant := db.ProductVariant{
-				Price:     input.Price,
-				Length:    input.Length,
-				Width:     input.Width,
-				Height:    input.Height,
-				Weight:    input.Weight,
-				ProductID: input.ProductID,
-			}
-			err = database.Insert(&variant)
-			if err != nil {
-				break
-			}
-
-			createdVariants = append(createdVariants, &variant)
-
-			options := make([]*db.ProductVariantOption, len(permutation))
-			for index, optionID := range permutation {
-				options[index] = &db.ProductVariantOption{
-					ProductOptionValueID: optionID,
-					ProductVariantID:     variant.ID,
-					ProductID:            input.ProductID,
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			if exists, err := tx.
+				Model(&db.ProductVariant{}).
+				Where("product_id = ?", input.ProductID).
+				Exists(); exists || err != nil {
+				return &core.WrappedError{
+					Message:       "Product already contains product vairants.",
+					InternalError: err,
 				}
 			}
 
-			err = database.Insert(&options)
-			if err != nil {
-				break
-			}
+			for _, permutation := range permutations {
+				variant := db.ProductVariant{
+					Price:       input.Price,
+					Length:      input.Length,
+					Width:       input.Width,
+					Height:      input.Height,
+					Weight:      input.Weight,
+					ProductID:   input.ProductID,
+					ShipsFromID: input.ShipsFromID,
+				}
+				if err := tx.Insert(&variant); err != nil {
+					return err
+				}
 
-			createdVariants[permutaitonIndex].SelectedOptions = options
-		}
+				options := make([]*db.ProductVariantOption, len(permutation))
+				for index, optionID := range permutation {
+					options[index] = &db.ProductVariantOption{
+						ProductOptionValueID: optionID,
+						ProductVariantID:     variant.ID,
+						ProductID:            input.ProductID,
+					}
+				}
 
-		// Rollback
-		if err != nil {
-			for _, variant := range createdVariants {
-				if variant.SelectedOptions != nil {
-					for _, selectedOption := range variant.SelectedOptions {
-						database.Delete(&selectedOption)
-						database.ForceDelete(&selectedOption)
+				if err := tx.Insert(&options); err != nil {
+					return err
+				}
+				variant.SelectedOptions = options
+
+				if input.Stock != 0 {
+					if err := adjustStock(tx, &db.InventoryAdjustment{
+						ProductVariantID: variant.ID,
+						Quantity:         input.Stock,
+						Reason:           InventoryReasonRestock,
+						Note:             "Initial stock.",
+						UserID:           claims.ID,
+					}); err != nil {
+						return err
 					}
+					variant.Stock = input.Stock
 				}
 
-				database.Delete(variant)
-				database.ForceDelete(variant)
+				createdVariants = append(createdVariants, &variant)
+			}
+
+			return nil
+		}); err != nil {
+			if wrapped, ok := err.(*core.WrappedError); ok {
+				return nil, wrapped
 			}
 
 			return nil, &core.WrappedError{
@@ -34,6 +34,12 @@ var ProductType = graphql.NewObject(
 				Type:        graphql.NewNonNull(graphql.Int),
 				Description: "An ID unique to the Product type. May conflict with other types.",
 			},
+			"createdAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"updatedAt": &graphql.Field{
+				Type: graphql.DateTime,
+			},
 			"slug": &graphql.Field{
 				Type:        graphql.NewNonNull(graphql.String),
 				Description: "A unique identifier for the product used in places like URL's.",
@@ -53,6 +59,10 @@ var ProductType = graphql.NewObject(
 			"published": &graphql.Field{
 				Type: graphql.Boolean,
 			},
+			"taxCode": &graphql.Field{
+				Type:        graphql.String,
+				Description: "The tax provider's code for the kind of goods the product is.",
+			},
 			"priceRange": &graphql.Field{
 				Type: graphql.NewObject(graphql.ObjectConfig{
 					Name: "ProductPriceRange",
@@ -213,6 +223,10 @@ var CreateProductInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
 			Type:        MarkdownScalar,
 			Description: "More in-depth details about the product in Markdown format.",
 		},
+		"taxCode": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "The tax provider's code for the kind of goods the product is.",
+		},
 	},
 })
 
@@ -231,6 +245,10 @@ var UpdateProductInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
 			Type:        MarkdownScalar,
 			Description: "More in-depth details about the product in Markdown format.",
 		},
+		"taxCode": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "The tax provider's code for the kind of goods the product is.",
+		},
 	},
 })
 
@@ -266,6 +284,7 @@ var CreateProductDraftField = &graphql.Field{
 		product.Slug = strings.TrimSpace(product.Slug)
 		product.Name = strings.TrimSpace(product.Name)
 		product.Description = strings.TrimSpace(product.Description)
+		product.TaxCode = strings.TrimSpace(product.TaxCode)
 
 		if product.Slug == "" {
 			return nil, fmt.Errorf("Slug is required.")
@@ -364,6 +383,7 @@ var UpdateProductField = &graphql.Field{
 		name := OptionalString(productInput, "name")
 		description := OptionalString(productInput, "description")
 		details := OptionalString(productInput, "details")
+		taxCode := OptionalString(productInput, "taxCode")
 
 		result := db.Product{ID: id}
 		if err := database.Select(&result); err != nil {
@@ -384,6 +404,9 @@ var UpdateProductField = &graphql.Field{
 		if details != nil {
 			result.Details = strings.TrimSpace(*details)
 		}
+		if taxCode != nil {
+			result.TaxCode = strings.TrimSpace(*taxCode)
+		}
 
 		if err := database.Update(&result); err != nil {
 			return nil, &core.WrappedError{
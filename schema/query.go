@@ -33,18 +33,26 @@ var QueryType = graphql.NewObject(graphql.ObjectConfig{
 			AuthRole:    "ADMIN",
 		}),
 
-		"subtotal":            SubtotalField,
-		"taxes":               TaxesField,
-		"shippingEstimations": ShippingEstimationsField,
+		"cart":                     CartField,
+		"subtotal":                 SubtotalField,
+		"taxes":                    TaxesField,
+		"cartTaxes":                CartTaxesField,
+		"shippingEstimations":      ShippingEstimationsField,
+		"shippingEstimationGroups": ShippingEstimationGroupsField,
 
 		"braintreeClientToken": BraintreeClientTokenField,
 
-		"transaction": TransactionField,
-		"transactions": NewPaginationField(PaginationFieldOpts{
-			Type:        TransactionType,
-			Dataloader:  "transactions",
-			Description: "Paginate through the transactions.",
-			AuthRole:    "ADMIN",
-		}),
+		"originAddresses": OriginAddressesField,
+		"shippingBoxes":   ShippingBoxesField,
+		"shippingZones":   ShippingZonesField,
+		"taxRules":        TaxRulesField,
+
+		"transaction":  TransactionField,
+		"transactions": TransactionsField,
+
+		"salesReport":        SalesReportField,
+		"salesTotals":        SalesTotalsField,
+		"topProducts":        TopProductsField,
+		"topProductVariants": TopProductVariantsField,
 	},
 })
@@ -16,6 +16,12 @@ var ReceiptLineItemType = graphql.NewObject(graphql.ObjectConfig{
 		"quantity": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
+		"tax": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"taxDetails": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(TaxDetailType)),
+		},
 		"variant": &graphql.Field{
 			Type: ProductVariantType,
 			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
@@ -39,6 +45,13 @@ var ReceiptType = graphql.NewObject(graphql.ObjectConfig{
 		"id": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
+		"createdAt": &graphql.Field{
+			Type:        graphql.DateTime,
+			Description: "When the order was placed.",
+		},
+		"updatedAt": &graphql.Field{
+			Type: graphql.DateTime,
+		},
 		"subtotal": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
@@ -48,9 +61,19 @@ var ReceiptType = graphql.NewObject(graphql.ObjectConfig{
 		"shipping": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
+		"shippingTax": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shippingTaxDetails": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(TaxDetailType)),
+		},
 		"total": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
+		"status":        TransactionStatusField,
+		"statusHistory": TransactionStatusHistoryField,
+		"refunds":       TransactionRefundsField,
+		"invoiceUrl":    InvoiceURLField,
 		"lineItems": &graphql.Field{
 			Type: graphql.NewList(graphql.NewNonNull(ReceiptLineItemType)),
 			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
@@ -0,0 +1,328 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+var RefundAmountError = fmt.Errorf("The refund must be more than zero and no more than what is left of the transaction.")
+var RefundQuantityError = fmt.Errorf("The refund quantity must be more than zero and no more than what is left of the line item.")
+var RefundLineItemError = fmt.Errorf("The line item does not belong to the transaction.")
+
+var RefundLineItemType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "RefundLineItem",
+	Fields: graphql.Fields{
+		"lineItemId": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				lineItem := params.Source.(*db.RefundLineItem)
+
+				return lineItem.TransactionLineItemID, nil
+			},
+		},
+		"quantity": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"amount": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "The price and tax of the refunded units in cents (¢).",
+		},
+	},
+})
+
+var TransactionRefundType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TransactionRefund",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+		},
+		"amount": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Int),
+			Description: "The amount refunded in cents (¢).",
+		},
+		"reason": &graphql.Field{
+			Type: graphql.String,
+		},
+		"lineItems": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(RefundLineItemType)),
+		},
+	},
+})
+
+var TransactionRefundsField = &graphql.Field{
+	Type: graphql.NewList(graphql.NewNonNull(TransactionRefundType)),
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		transactionRefunds := params.Context.Value("transactionRefunds").(*dataloader.Loader)
+
+		transaction := params.Source.(*db.Transaction)
+
+		thunk := transactionRefunds.Load(params.Context, dataloaders.IntKey(transaction.ID))
+
+		return func() (interface{}, error) {
+			return thunk()
+		}, nil
+	},
+}
+
+// Records a refund the store made. The payment webhook can record the same refund first, in which
+// case the store's reason, line items and user replace the webhook's.
+func insertTransactionRefund(database orm.DB, refund *db.TransactionRefund) error {
+	_, err := database.
+		Model(refund).
+		OnConflict("(gateway_id) DO UPDATE").
+		Set("reason = EXCLUDED.reason").
+		Set("line_items = EXCLUDED.line_items").
+		Set("user_id = EXCLUDED.user_id").
+		Insert()
+
+	return err
+}
+
+// The price of the units plus their share of the line item's tax.
+func refundAmountForLineItem(lineItem *db.TransactionLineItem, quantity int) int {
+	tax := int(math.Round(float64(lineItem.Tax) * float64(quantity) / float64(lineItem.Quantity)))
+
+	return lineItem.Price*quantity + tax
+}
+
+var RefundLineItemInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "RefundLineItemInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"lineItemId": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"quantity": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+})
+
+var RefundTransactionField = &graphql.Field{
+	Type:        TransactionType,
+	Description: "Refund a transaction. Refunds the price and tax of the line items when they are provided, the amount when it is provided, or what is left of the transaction otherwise.",
+	Args: graphql.FieldConfigArgument{
+		"transactionId": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"lineItems": &graphql.ArgumentConfig{
+			Type: graphql.NewList(graphql.NewNonNull(RefundLineItemInputSchema)),
+		},
+		"amount": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "The amount to refund in cents (¢). Overrides the amount of the line items, to include shipping for example.",
+		},
+		"reason": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"restock": &graphql.ArgumentConfig{
+			Type:         graphql.Boolean,
+			DefaultValue: true,
+			Description:  "Put the refunded units of the line items back in stock. Turn off for units that were not returned.",
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+		paymentGateway := params.Context.Value("paymentGateway").(services.PaymentGateway)
+		transactionLoader := params.Context.Value("transaction").(*dataloader.Loader)
+		transactionRefundsLoader := params.Context.Value("transactionRefunds").(*dataloader.Loader)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		transactionID := params.Args["transactionId"].(int)
+		amount := OptionalInt(params.Args, "amount")
+		reason := OptionalString(params.Args, "reason")
+
+		lineItemInputs := []struct {
+			LineItemID int
+			Quantity   int
+		}{}
+		if lineItemsTemp, ok := params.Args["lineItems"]; ok {
+			if err := ConvertObject(lineItemsTemp, &lineItemInputs); err != nil {
+				return nil, &core.WrappedError{
+					Message:       "Could not convert lineItems argument.",
+					InternalError: err,
+				}
+			}
+		}
+
+		tempTransaction, err := transactionLoader.Load(params.Context, dataloaders.IntKey(transactionID))()
+		if err != nil {
+			return nil, err
+		}
+		transaction := tempTransaction.(*db.Transaction)
+
+		if transaction.BraintreeID == "" {
+			return nil, TransactionNotPaidError
+		}
+		if isPaymentAuthorized(transaction) {
+			return nil, TransactionNotCapturedError
+		}
+
+		refund := db.TransactionRefund{
+			TransactionID: transaction.ID,
+			LineItems:     []*db.RefundLineItem{},
+			UserID:        claims.ID,
+		}
+		if reason != nil {
+			refund.Reason = *reason
+		}
+
+		// The transaction stays locked until the refund is recorded so refunds made at the same time
+		// can not add up to more than the transaction.
+		refundedPayment := false
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			if err := tx.Model(transaction).WherePK().For("UPDATE").Select(); err != nil {
+				return &core.WrappedError{
+					Message:       "Could not find transaction.",
+					InternalError: err,
+				}
+			}
+
+			lineItems := []*db.TransactionLineItem{}
+			if err := tx.Model(&lineItems).Where("transaction_id = ?", transaction.ID).Select(); err != nil {
+				return &core.WrappedError{
+					Message:       "Could not get line items.",
+					InternalError: err,
+				}
+			}
+			lineItemMap := map[int]*db.TransactionLineItem{}
+			for _, lineItem := range lineItems {
+				lineItemMap[lineItem.ID] = lineItem
+			}
+
+			refunds := []*db.TransactionRefund{}
+			if err := tx.Model(&refunds).Where("transaction_id = ?", transaction.ID).Select(); err != nil {
+				return &core.WrappedError{
+					Message:       "Could not get refunds.",
+					InternalError: err,
+				}
+			}
+			refunded := 0
+			refundedQuantities := map[int]int{}
+			for _, previous := range refunds {
+				refunded += previous.Amount
+				for _, lineItem := range previous.LineItems {
+					refundedQuantities[lineItem.TransactionLineItemID] += lineItem.Quantity
+				}
+			}
+
+			restocks := []*db.InventoryAdjustment{}
+			for _, input := range lineItemInputs {
+				lineItem, ok := lineItemMap[input.LineItemID]
+				if !ok {
+					return RefundLineItemError
+				}
+
+				refundedQuantities[lineItem.ID] += input.Quantity
+				if input.Quantity <= 0 || refundedQuantities[lineItem.ID] > lineItem.Quantity {
+					return RefundQuantityError
+				}
+
+				lineItemAmount := refundAmountForLineItem(lineItem, input.Quantity)
+				refund.Amount += lineItemAmount
+				refund.LineItems = append(refund.LineItems, &db.RefundLineItem{
+					TransactionLineItemID: lineItem.ID,
+					Quantity:              input.Quantity,
+					Amount:                lineItemAmount,
+				})
+
+				restocks = append(restocks, &db.InventoryAdjustment{
+					ProductVariantID:      lineItem.ProductVariantID,
+					Quantity:              input.Quantity,
+					Reason:                InventoryReasonReturn,
+					UserID:                claims.ID,
+					TransactionLineItemID: lineItem.ID,
+				})
+			}
+
+			if amount != nil {
+				refund.Amount = *amount
+			} else if len(lineItemInputs) == 0 {
+				refund.Amount = transaction.Total - refunded
+			}
+
+			if refund.Amount <= 0 || refunded+refund.Amount > transaction.Total {
+				return RefundAmountError
+			}
+
+			paymentTransaction, err := paymentGateway.Refund(params.Context, transaction.BraintreeID, refund.Amount)
+			if err != nil {
+				return &core.WrappedError{
+					Message:       "Could not refund payment.",
+					InternalError: err,
+				}
+			}
+			refundedPayment = true
+			refund.GatewayID = paymentTransaction.ID
+			refund.CreatedAt = time.Now()
+
+			if err := insertTransactionRefund(tx, &refund); err != nil {
+				return err
+			}
+
+			if restock, _ := params.Args["restock"].(bool); restock {
+				for _, adjustment := range restocks {
+					adjustment.Note = "Refund " + refund.GatewayID
+					if err := adjustStock(tx, adjustment); err != nil {
+						return err
+					}
+				}
+			}
+
+			status := db.TransactionStatus{
+				CreatedAt:     refund.CreatedAt,
+				TransactionID: transaction.ID,
+				Status:        TransactionStatusPartiallyRefunded,
+			}
+			if refunded+refund.Amount == transaction.Total {
+				status.Status = TransactionStatusRefunded
+			}
+
+			return appendTransactionStatus(tx, &status)
+		}); err != nil {
+			if !refundedPayment {
+				return nil, err
+			}
+
+			fmt.Println("Failed to record refund " + refund.GatewayID + ".")
+			fmt.Println(err)
+
+			// The payment was refunded, so the refund is kept without the restock or the status.
+			refund.ID = 0
+			if err := insertTransactionRefund(database, &refund); err != nil {
+				fmt.Println("Failed to record refund " + refund.GatewayID + ".")
+				fmt.Println(err)
+			}
+
+			return nil, &core.WrappedError{
+				Message:       "The payment was refunded, but the refund could not be recorded.",
+				InternalError: err,
+			}
+		}
+
+		transactionRefundsLoader.Clear(params.Context, dataloaders.IntKey(transaction.ID))
+
+		return transaction, nil
+	},
+}
@@ -70,6 +70,28 @@ func ConvertObject(input interface{}, output interface{}) error {
 	return nil
 }
 
+var PaginationSortEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "PaginationSort",
+	Values: graphql.EnumValueConfigMap{
+		dataloaders.PaginationSortNewest: &graphql.EnumValueConfig{
+			Value:       dataloaders.PaginationSortNewest,
+			Description: "The most recently created first.",
+		},
+		dataloaders.PaginationSortOldest: &graphql.EnumValueConfig{
+			Value:       dataloaders.PaginationSortOldest,
+			Description: "The first created first.",
+		},
+		dataloaders.PaginationSortRecentlyUpdated: &graphql.EnumValueConfig{
+			Value:       dataloaders.PaginationSortRecentlyUpdated,
+			Description: "The most recently updated first.",
+		},
+		dataloaders.PaginationSortLeastUpdated: &graphql.EnumValueConfig{
+			Value:       dataloaders.PaginationSortLeastUpdated,
+			Description: "The least recently updated first.",
+		},
+	},
+})
+
 // Options used to create a new pagination field.
 type PaginationFieldOpts struct {
 	Description string
@@ -91,6 +113,9 @@ func NewPaginationField(options PaginationFieldOpts) *graphql.Field {
 			"limit": &graphql.ArgumentConfig{
 				Type: graphql.Int,
 			},
+			"sort": &graphql.ArgumentConfig{
+				Type: PaginationSortEnum,
+			},
 		},
 		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
 			loader := params.Context.Value(options.Dataloader).(*dataloader.Loader)
@@ -109,6 +134,7 @@ func NewPaginationField(options PaginationFieldOpts) *graphql.Field {
 
 			skip, _ := params.Args["skip"].(int)
 			limit, _ := params.Args["limit"].(int)
+			sort, _ := params.Args["sort"].(string)
 
 			if skip < 0 {
 				skip = 0
@@ -121,6 +147,7 @@ func NewPaginationField(options PaginationFieldOpts) *graphql.Field {
 			thunk := loader.Load(params.Context, dataloaders.PaginationKey{
 				Skip:  skip,
 				Limit: limit,
+				Sort:  sort,
 			})
 
 			return func() (interface{}, error) {
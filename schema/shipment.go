@@ -0,0 +1,155 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+func retrieveShippingEstimation(ctx context.Context, rateID string) (*services.ShippingEstimation, error) {
+	shippingProvider := ctx.Value("shippingProvider").(services.ShippingProvider)
+
+	return shippingProvider.RetrieveRate(ctx, rateID)
+}
+
+func retrieveShippingLabel(ctx context.Context, labelID string) (*services.ShippingLabel, error) {
+	shippingProvider := ctx.Value("shippingProvider").(services.ShippingProvider)
+
+	return shippingProvider.RetrieveLabel(ctx, labelID)
+}
+
+var ServiceUnavailableError = fmt.Errorf("The selected shipping service is no longer available for the shipment's parcels.")
+
+// Rates the parcels the shipment was packed into at checkout again and finds the rate for the same carrier
+// and service as the selected one, for when the selected rate has expired.
+func ratePackedShipment(
+	ctx context.Context,
+	transactionID int,
+	shipment *db.TransactionShipment,
+	selected *services.ShippingEstimation) (*services.ShippingEstimation, error) {
+	shippingProvider := ctx.Value("shippingProvider").(services.ShippingProvider)
+	addressLoader := ctx.Value("address").(*dataloader.Loader)
+	transactionParcelsLoader := ctx.Value("transactionParcels").(*dataloader.Loader)
+	transactionAddressesLoader := ctx.Value("transactionAddresses").(*dataloader.Loader)
+
+	tempParcels, err := transactionParcelsLoader.Load(ctx, dataloaders.IntKey(shipment.ID))()
+	if err != nil {
+		return nil, err
+	}
+	parcels, _ := tempParcels.([]*db.TransactionParcel)
+
+	// Shipments from before orders were packed were rated with one parcel per unit.
+	if len(parcels) == 0 {
+		return selected, nil
+	}
+
+	tempOrigin, err := addressLoader.Load(ctx, dataloaders.IntKey(shipment.OriginID))()
+	if err != nil {
+		return nil, err
+	}
+	origin := tempOrigin.(*db.Address)
+
+	tempAddresses, err := transactionAddressesLoader.Load(ctx, dataloaders.IntKey(transactionID))()
+	if err != nil {
+		return nil, err
+	}
+	addresses := tempAddresses.(*db.TransactionAddressInfo)
+
+	shippingParcels := make([]services.ShippingParcel, len(parcels))
+	for index, parcel := range parcels {
+		shippingParcels[index] = services.ShippingParcel{
+			Length: parcel.Length,
+			Width:  parcel.Width,
+			Height: parcel.Height,
+			Weight: parcel.Weight,
+		}
+	}
+
+	rates, err := shippingProvider.RateParcels(ctx, *origin, *addresses.ShippingAddress, shippingParcels)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rate := range rates {
+		if rate.Carrier == selected.Carrier && rate.ServiceToken == selected.ServiceToken {
+			return rate, nil
+		}
+	}
+
+	return nil, ServiceUnavailableError
+}
+
+var TransactionShipmentType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "TransactionShipment",
+	Description: "A package of a transaction's line items that ships from a single origin.",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shipping": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shippoRateId": &graphql.Field{
+			Type: graphql.String,
+		},
+		"origin": &graphql.Field{
+			Type: AddressType,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				addressLoader := params.Context.Value("address").(*dataloader.Loader)
+
+				shipment := params.Source.(*db.TransactionShipment)
+
+				thunk := addressLoader.Load(params.Context, dataloaders.IntKey(shipment.OriginID))
+
+				return func() (interface{}, error) {
+					return thunk()
+				}, nil
+			},
+		},
+		"parcels": &graphql.Field{
+			Type:        graphql.NewList(graphql.NewNonNull(TransactionParcelType)),
+			Description: "The parcels the shipment was packed into at checkout.",
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				transactionParcelsLoader := params.Context.Value("transactionParcels").(*dataloader.Loader)
+
+				shipment := params.Source.(*db.TransactionShipment)
+
+				thunk := transactionParcelsLoader.Load(params.Context, dataloaders.IntKey(shipment.ID))
+
+				return func() (interface{}, error) {
+					return thunk()
+				}, nil
+			},
+		},
+		"shippingEstimation": &graphql.Field{
+			Type: ShippingRateType,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				shipment := params.Source.(*db.TransactionShipment)
+
+				if shipment.ShippoRateID == "" {
+					return nil, nil
+				}
+
+				return retrieveShippingEstimation(params.Context, shipment.ShippoRateID)
+			},
+		},
+		"shippingLabel": &graphql.Field{
+			Type: ShippingLabelType,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				shipment := params.Source.(*db.TransactionShipment)
+
+				if shipment.ShippoTransactionID == "" {
+					return nil, nil
+				}
+
+				return retrieveShippingLabel(params.Context, shipment.ShippoTransactionID)
+			},
+		},
+	},
+})
@@ -0,0 +1,373 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+var InvalidShippingBoxError = fmt.Errorf("Shipping box dimensions and max weight must be greater than zero, and the box can't weigh more than its max weight.")
+
+func validateShippingBox(box *db.ShippingBox) error {
+	if box.Length <= 0 || box.Width <= 0 || box.Height <= 0 || box.MaxWeight <= 0 ||
+		box.Weight < 0 || box.Weight >= box.MaxWeight {
+		return InvalidShippingBoxError
+	}
+
+	return nil
+}
+
+var ShippingBoxType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "ShippingBox",
+	Description: "A box size orders are packed into. Dimensions are in inches and weights in ounces.",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"name": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"length": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"width": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"height": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"weight": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Float),
+			Description: "The weight of the empty box.",
+		},
+		"maxWeight": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Float),
+			Description: "The max weight of the packed box, including the box.",
+		},
+	},
+})
+
+var ParcelContentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ParcelContent",
+	Fields: graphql.Fields{
+		"variantId": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				content := params.Source.(*db.ParcelContent)
+
+				return content.ProductVariantID, nil
+			},
+		},
+		"quantity": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"variant": &graphql.Field{
+			Type: ProductVariantType,
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				productVariantLoader := params.Context.Value("productVariant").(*dataloader.Loader)
+
+				content := params.Source.(*db.ParcelContent)
+
+				thunk := productVariantLoader.Load(params.Context, dataloaders.IntKey(content.ProductVariantID))
+
+				return func() (interface{}, error) {
+					return thunk()
+				}, nil
+			},
+		},
+	},
+})
+
+var TransactionParcelType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "TransactionParcel",
+	Description: "A parcel of a shipment's packing plan.",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"box": &graphql.Field{
+			Type:        ShippingBoxType,
+			Description: "The box to pack the contents in. Null when the item ships in its own packaging.",
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				database := params.Context.Value("database").(*pg.DB)
+
+				parcel := params.Source.(*db.TransactionParcel)
+
+				if parcel.ShippingBoxID == 0 {
+					return nil, nil
+				}
+
+				box := db.ShippingBox{}
+				if err := database.
+					Model(&box).
+					Where("shipping_box.id = ?", parcel.ShippingBoxID).
+					AllWithDeleted().
+					Select(); err != nil {
+					return nil, &core.WrappedError{
+						Message:       "Could not get shipping box.",
+						InternalError: err,
+					}
+				}
+
+				return &box, nil
+			},
+		},
+		"length": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"width": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"height": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"weight": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"contents": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(ParcelContentType)),
+		},
+	},
+})
+
+var ShippingBoxesField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(ShippingBoxType)),
+	Description: "The box sizes orders are packed into.",
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		boxes := []*db.ShippingBox{}
+		if err := database.
+			Model(&boxes).
+			OrderExpr("shipping_box.id ASC").
+			Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not get shipping boxes.",
+				InternalError: err,
+			}
+		}
+
+		return boxes, nil
+	},
+}
+
+var CreateShippingBoxInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateShippingBoxInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"length": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"width": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"height": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"weight": &graphql.InputObjectFieldConfig{
+			Type:         graphql.Float,
+			DefaultValue: 0.0,
+		},
+		"maxWeight": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+	},
+})
+
+var CreateShippingBoxField = &graphql.Field{
+	Type:        ShippingBoxType,
+	Description: "Create a box size to pack orders into.",
+	Args: graphql.FieldConfigArgument{
+		"input": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(CreateShippingBoxInputSchema),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		box := db.ShippingBox{}
+		if err := ConvertObject(params.Args["input"], &box); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not convert input.",
+				InternalError: err,
+			}
+		}
+		box.Name = strings.TrimSpace(box.Name)
+
+		if err := validateShippingBox(&box); err != nil {
+			return nil, err
+		}
+
+		if err := database.Insert(&box); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not create shipping box.",
+				InternalError: err,
+			}
+		}
+
+		return &box, nil
+	},
+}
+
+var UpdateShippingBoxInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "UpdateShippingBoxInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"length": &graphql.InputObjectFieldConfig{
+			Type: graphql.Float,
+		},
+		"width": &graphql.InputObjectFieldConfig{
+			Type: graphql.Float,
+		},
+		"height": &graphql.InputObjectFieldConfig{
+			Type: graphql.Float,
+		},
+		"weight": &graphql.InputObjectFieldConfig{
+			Type: graphql.Float,
+		},
+		"maxWeight": &graphql.InputObjectFieldConfig{
+			Type: graphql.Float,
+		},
+	},
+})
+
+var UpdateShippingBoxField = &graphql.Field{
+	Type:        ShippingBoxType,
+	Description: "Update a box size. Orders that were already packed keep their parcels.",
+	Args: graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"input": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(UpdateShippingBoxInputSchema),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		id := params.Args["id"].(int)
+		input := params.Args["input"].(map[string]interface{})
+		name := OptionalString(input, "name")
+		length := OptionalFloat(input, "length")
+		width := OptionalFloat(input, "width")
+		height := OptionalFloat(input, "height")
+		weight := OptionalFloat(input, "weight")
+		maxWeight := OptionalFloat(input, "maxWeight")
+
+		box := db.ShippingBox{ID: id}
+		if err := database.Select(&box); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not find shipping box to update.",
+				InternalError: err,
+			}
+		}
+
+		if name != nil {
+			box.Name = strings.TrimSpace(*name)
+		}
+		if length != nil {
+			box.Length = *length
+		}
+		if width != nil {
+			box.Width = *width
+		}
+		if height != nil {
+			box.Height = *height
+		}
+		if weight != nil {
+			box.Weight = *weight
+		}
+		if maxWeight != nil {
+			box.MaxWeight = *maxWeight
+		}
+
+		if err := validateShippingBox(&box); err != nil {
+			return nil, err
+		}
+
+		if err := database.Update(&box); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not update shipping box.",
+				InternalError: err,
+			}
+		}
+
+		return &box, nil
+	},
+}
+
+var RemoveShippingBoxField = &graphql.Field{
+	Type:        ShippingBoxType,
+	Description: "Remove a box size. Orders that were already packed keep their parcels.",
+	Args: graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		id := params.Args["id"].(int)
+
+		toDelete := db.ShippingBox{}
+		if err := database.Model(&toDelete).Where("id = ?", id).Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not retrieve shipping box to remove.",
+				InternalError: err,
+			}
+		}
+
+		if err := database.Delete(&toDelete); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not remove shipping box.",
+				InternalError: err,
+			}
+		}
+
+		return &toDelete, nil
+	},
+}
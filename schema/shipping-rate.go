@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"fmt"
+
 	"github.com/graph-gophers/dataloader"
 	"github.com/graphql-go/graphql"
 	"github.com/jacob-ebey/golang-ecomm/dataloaders"
@@ -30,9 +32,81 @@ var ShippingRateType = graphql.NewObject(
 	},
 )
 
+var ShippingEstimationGroupType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name:        "ShippingEstimationGroup",
+		Description: "The cart items that ship from a single origin, and the rates to ship them.",
+		Fields: graphql.Fields{
+			"origin": &graphql.Field{
+				Type: graphql.NewNonNull(AddressType),
+			},
+			"variants": &graphql.Field{
+				Type: graphql.NewList(graphql.NewNonNull(CartItemType)),
+			},
+			"rates": &graphql.Field{
+				Type: graphql.NewList(ShippingRateType),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					group := params.Source.(*dataloaders.ShippingEstimationGroup)
+
+					return group.Estimations, nil
+				},
+			},
+		},
+	},
+)
+
+var MultipleOriginsError = fmt.Errorf("The variants ship from more than one origin. Use shippingEstimationGroups instead.")
+
 var ShippingEstimationsField = &graphql.Field{
 	Type:        graphql.NewList(ShippingRateType),
-	Description: "Get shipping estimations for the provided variants and quantities.",
+	Description: "Get shipping estimations for the provided variants and quantities. Fails if the variants ship from more than one origin.",
+	Args: graphql.FieldConfigArgument{
+		"address": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(AddressInputSchema),
+		},
+		"variants": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.NewList(
+				graphql.NewNonNull(CartInputSchema),
+			)),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		shippingEstimations := params.Context.Value("shippingEstimations").(*dataloader.Loader)
+
+		key := dataloaders.ShippingEstimationKey{}
+		if err := ConvertObject(params.Args, &key); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not convert arguments.",
+				InternalError: err,
+			}
+		}
+
+		thunk := shippingEstimations.Load(params.Context, key)
+
+		return func() (interface{}, error) {
+			result, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+
+			groups := result.([]*dataloaders.ShippingEstimationGroup)
+
+			if len(groups) == 0 {
+				return nil, nil
+			}
+
+			if len(groups) > 1 {
+				return nil, MultipleOriginsError
+			}
+
+			return groups[0].Estimations, nil
+		}, nil
+	},
+}
+
+var ShippingEstimationGroupsField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(ShippingEstimationGroupType)),
+	Description: "Get shipping estimations for the provided variants and quantities, grouped by the origin they ship from. Groups are ordered by origin.",
 	Args: graphql.FieldConfigArgument{
 		"address": &graphql.ArgumentConfig{
 			Type: graphql.NewNonNull(AddressInputSchema),
@@ -0,0 +1,328 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+var InvalidShippingZoneRateError = fmt.Errorf("Shipping zone rates need a carrier, a service, a max weight greater than zero and a price of at least zero.")
+
+var ShippingZoneRateType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "ShippingZoneRate",
+	Description: "The price in cents to ship a parcel weighing up to maxWeight ounces to a zone with a service.",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"carrier": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"service": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"durationTerms": &graphql.Field{
+			Type: graphql.String,
+		},
+		"maxWeight": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"price": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+})
+
+var ShippingZoneType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "ShippingZone",
+	Description: "A destination the table rate shipping provider rates to.",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"name": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"country": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"region": &graphql.Field{
+			Type: graphql.String,
+		},
+		"postalCodePrefix": &graphql.Field{
+			Type: graphql.String,
+		},
+		"rates": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(ShippingZoneRateType)),
+		},
+	},
+})
+
+var ShippingZonesField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(ShippingZoneType)),
+	Description: "The zones and rates used by the table rate shipping provider.",
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		zones := []*db.ShippingZone{}
+		if err := database.
+			Model(&zones).
+			Relation("Rates", func(query *orm.Query) (*orm.Query, error) {
+				return query.OrderExpr("shipping_zone_rate.id ASC"), nil
+			}).
+			OrderExpr("shipping_zone.id ASC").
+			Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not get shipping zones.",
+				InternalError: err,
+			}
+		}
+
+		return zones, nil
+	},
+}
+
+var CreateShippingZoneInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateShippingZoneInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"country": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"region": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"postalCodePrefix": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+	},
+})
+
+var CreateShippingZoneField = &graphql.Field{
+	Type:        ShippingZoneType,
+	Description: "Create a zone for the table rate shipping provider. Leave the region and postal code prefix empty to match the whole country.",
+	Args: graphql.FieldConfigArgument{
+		"input": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(CreateShippingZoneInputSchema),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		zone := db.ShippingZone{}
+		if err := ConvertObject(params.Args["input"], &zone); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not convert input.",
+				InternalError: err,
+			}
+		}
+		zone.Name = strings.TrimSpace(zone.Name)
+		zone.Country = strings.TrimSpace(zone.Country)
+		zone.Region = strings.TrimSpace(zone.Region)
+		zone.PostalCodePrefix = strings.TrimSpace(zone.PostalCodePrefix)
+
+		if err := database.Insert(&zone); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not create shipping zone.",
+				InternalError: err,
+			}
+		}
+
+		return &zone, nil
+	},
+}
+
+var RemoveShippingZoneField = &graphql.Field{
+	Type:        ShippingZoneType,
+	Description: "Remove a shipping zone and its rates.",
+	Args: graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		id := params.Args["id"].(int)
+
+		toDelete := db.ShippingZone{}
+		if err := database.Model(&toDelete).Where("id = ?", id).Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not retrieve shipping zone to remove.",
+				InternalError: err,
+			}
+		}
+
+		if _, err := database.
+			Model(&db.ShippingZoneRate{}).
+			Where("shipping_zone_id = ?", id).
+			Delete(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not remove shipping zone rates.",
+				InternalError: err,
+			}
+		}
+
+		if err := database.Delete(&toDelete); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not remove shipping zone.",
+				InternalError: err,
+			}
+		}
+
+		return &toDelete, nil
+	},
+}
+
+var CreateShippingZoneRateInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateShippingZoneRateInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"carrier": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"service": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"durationTerms": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"maxWeight": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"price": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+})
+
+var CreateShippingZoneRateField = &graphql.Field{
+	Type:        ShippingZoneRateType,
+	Description: "Add a weight tier for a service to a shipping zone.",
+	Args: graphql.FieldConfigArgument{
+		"shippingZoneId": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"input": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(CreateShippingZoneRateInputSchema),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		rate := db.ShippingZoneRate{}
+		if err := ConvertObject(params.Args["input"], &rate); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not convert input.",
+				InternalError: err,
+			}
+		}
+		rate.ShippingZoneID = params.Args["shippingZoneId"].(int)
+		rate.Carrier = strings.TrimSpace(rate.Carrier)
+		rate.Service = strings.TrimSpace(rate.Service)
+
+		if rate.Carrier == "" || rate.Service == "" || rate.MaxWeight <= 0 || rate.Price < 0 {
+			return nil, InvalidShippingZoneRateError
+		}
+
+		exists, err := database.
+			Model(&db.ShippingZone{}).
+			Where("id = ?", rate.ShippingZoneID).
+			Exists()
+		if err != nil || !exists {
+			return nil, &core.WrappedError{
+				Message:       "Could not find shipping zone.",
+				InternalError: err,
+			}
+		}
+
+		if err := database.Insert(&rate); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not create shipping zone rate.",
+				InternalError: err,
+			}
+		}
+
+		return &rate, nil
+	},
+}
+
+var RemoveShippingZoneRateField = &graphql.Field{
+	Type:        ShippingZoneRateType,
+	Description: "Remove a weight tier from a shipping zone.",
+	Args: graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		id := params.Args["id"].(int)
+
+		toDelete := db.ShippingZoneRate{}
+		if err := database.Model(&toDelete).Where("id = ?", id).Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not retrieve shipping zone rate to remove.",
+				InternalError: err,
+			}
+		}
+
+		if err := database.Delete(&toDelete); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not remove shipping zone rate.",
+				InternalError: err,
+			}
+		}
+
+		return &toDelete, nil
+	},
+}
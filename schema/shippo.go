@@ -6,17 +6,59 @@ import (
 	"github.com/go-pg/pg/v9"
 	"github.com/graph-gophers/dataloader"
 	"github.com/graphql-go/graphql"
-	"github.com/jacob-ebey/go-shippo/client"
-	"github.com/jacob-ebey/go-shippo/models"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
 	"github.com/jacob-ebey/golang-ecomm/dataloaders"
 	"github.com/jacob-ebey/golang-ecomm/db"
 	"github.com/jacob-ebey/golang-ecomm/email"
-	core "github.com/jacob-ebey/graphql-core"
+	"github.com/jacob-ebey/golang-ecomm/services"
 )
 
+var RateNotForShipmentError = fmt.Errorf("The shipping rate does not belong to a shipment of the transaction.")
+var LabelAlreadyPurchasedError = fmt.Errorf("A label has already been purchased for the shipment.")
+var ShippingPriceChangedError = fmt.Errorf("The shipment's parcels no longer rate at the price the customer paid for shipping. Set acceptPriceChange to buy the label anyway.")
+
+// Stores the purchased label on the shipment and marks the transaction as shipped.
+func recordShippingLabel(
+	tx *pg.Tx,
+	transaction *db.Transaction,
+	shipment *db.TransactionShipment,
+	shipmentCount int,
+	label *services.ShippingLabel) error {
+	if shipment != nil {
+		shipment.ShippoTransactionID = label.ID
+
+		if err := tx.Update(shipment); err != nil {
+			return &core.WrappedError{
+				Message:       "Could not update transaction shipment with shippo transaction id.",
+				InternalError: err,
+			}
+		}
+	}
+
+	if shipment == nil || shipmentCount == 1 {
+		transaction.ShippoTransactionID = label.ID
+
+		if err := tx.Update(transaction); err != nil {
+			return &core.WrappedError{
+				Message:       "Could not update transaction with shippo transaction id.",
+				InternalError: err,
+			}
+		}
+	}
+
+	return appendTransactionStatus(tx, &db.TransactionStatus{
+		TransactionID: transaction.ID,
+		Status:        TransactionStatusShipped,
+		Carrier:       label.Carrier,
+		TrackingID:    label.TrackingNumber,
+	})
+}
+
 var PurchaseShippoLabelField = &graphql.Field{
 	Type:        ShippingLabelType,
-	Description: "Purchase a shippo label for a transaction.",
+	Description: "Purchase a shipping label for a transaction. The rate must be the rate of one of the transaction's shipments so the label ships from that shipment's origin.",
 	Args: graphql.FieldConfigArgument{
 		"transactionId": &graphql.ArgumentConfig{
 			Type: graphql.NewNonNull(graphql.Int),
@@ -24,14 +66,31 @@ var PurchaseShippoLabelField = &graphql.Field{
 		"shippoRateId": &graphql.ArgumentConfig{
 			Type: graphql.NewNonNull(graphql.String),
 		},
+		"rerate": &graphql.ArgumentConfig{
+			Type:        graphql.Boolean,
+			Description: "Rate the shipment's parcels again for the same service, for when the rate has expired.",
+		},
+		"acceptPriceChange": &graphql.ArgumentConfig{
+			Type:        graphql.Boolean,
+			Description: "Buy a label that was rated again even when it costs more or less than the customer paid for shipping.",
+		},
 	},
 	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
 		database := params.Context.Value("database").(*pg.DB)
 		transactionLoader := params.Context.Value("transaction").(*dataloader.Loader)
+		transactionShipmentsLoader := params.Context.Value("transactionShipments").(*dataloader.Loader)
 		userLoader := params.Context.Value("user").(*dataloader.Loader)
-		shippoClient := params.Context.Value("shippo").(*client.Client)
+		shippingProvider := params.Context.Value("shippingProvider").(services.ShippingProvider)
 		emailClient := params.Context.Value("email").(email.Client)
 
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
 		transactionId := params.Args["transactionId"].(int)
 		shippoRateID := params.Args["shippoRateId"].(string)
 
@@ -40,59 +99,134 @@ var PurchaseShippoLabelField = &graphql.Field{
 			return nil, err
 		}
 		transaction := tempTransaction.(*db.Transaction)
+		if isPaymentPending(transaction) {
+			return nil, PaymentPendingError
+		}
 
-		rate, err := shippoClient.RetrieveRate(shippoRateID)
-		if err != nil || rate == nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not retrieve shipping rate.",
-				InternalError: err,
+		tempShipments, err := transactionShipmentsLoader.Load(params.Context, dataloaders.IntKey(transactionId))()
+		if err != nil {
+			return nil, err
+		}
+		shipments, _ := tempShipments.([]*db.TransactionShipment)
+
+		// Transactions from before shipments were tracked only have the rate on the transaction.
+		var shipment *db.TransactionShipment
+		if len(shipments) > 0 {
+			for _, candidate := range shipments {
+				if candidate.ShippoRateID == shippoRateID {
+					shipment = candidate
+					break
+				}
+			}
+
+			if shipment == nil {
+				return nil, RateNotForShipmentError
+			}
+
+			if shipment.ShippoTransactionID != "" {
+				return nil, LabelAlreadyPurchasedError
 			}
 		}
 
-		label, err := shippoClient.PurchaseShippingLabel(&models.TransactionInput{
-			Rate:          rate.ObjectID,
-			LabelFileType: models.LabelFileTypePDF,
-			Async:         false,
-		})
+		rate, err := retrieveShippingEstimation(params.Context, shippoRateID)
 		if err != nil {
-			return nil, &core.WrappedError{
-				Message:       "Could not purchase shipping label.",
-				InternalError: err,
-			}
+			return nil, err
 		}
 
-		if label.Status == "ERROR" {
-			message := "Could not purchase shipping label."
-			if len(label.Messages) > 0 {
-				message = label.Messages[0].Text
+		// The label is bought with the rate the customer paid for. Rates expire, so an admin can
+		// rate the packed parcels again when it can no longer be bought.
+		if rerate, _ := params.Args["rerate"].(bool); rerate && shipment != nil {
+			rate, err = ratePackedShipment(params.Context, transaction.ID, shipment, rate)
+			if err != nil {
+				return nil, err
 			}
 
-			return nil, &core.WrappedError{
-				Message: message,
+			if acceptPriceChange, _ := params.Args["acceptPriceChange"].(bool); rate.Price != shipment.Shipping && !acceptPriceChange {
+				return nil, ShippingPriceChangedError
 			}
 		}
 
-		transaction.ShippoTransactionID = label.ObjectID
-
-		status := &db.TransactionStatus{
-			TransactionID: transaction.ID,
-			Status:        "SHIPPED",
-			Carrier:       rate.Provider,
-			TrackingID:    label.TrackingNumber,
+		current, err := currentTransactionStatus(database, transaction.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateTransactionStatusTransition(current, TransactionStatusShipped); err != nil {
+			return nil, err
 		}
 
-		if err := database.Update(transaction); err != nil {
-			fmt.Println("Failed to update transaction with shippo transaction id.")
+		// The transaction stays locked while the label is bought so it is only bought once.
+		var label *services.ShippingLabel
+		if err := database.RunInTransaction(func(tx *pg.Tx) error {
+			if err := tx.Model(transaction).WherePK().For("UPDATE").Select(); err != nil {
+				return &core.WrappedError{
+					Message:       "Could not find transaction.",
+					InternalError: err,
+				}
+			}
+
+			if shipment != nil {
+				if err := tx.Select(shipment); err != nil {
+					return &core.WrappedError{
+						Message:       "Could not find transaction shipment.",
+						InternalError: err,
+					}
+				}
+
+				if shipment.ShippoTransactionID != "" {
+					return LabelAlreadyPurchasedError
+				}
+			} else if transaction.ShippoTransactionID != "" {
+				return LabelAlreadyPurchasedError
+			}
+
+			current, err := currentTransactionStatus(tx, transaction.ID)
+			if err != nil {
+				return err
+			}
+			if err := validateTransactionStatusTransition(current, TransactionStatusShipped); err != nil {
+				return err
+			}
+
+			label, err = shippingProvider.PurchaseLabel(params.Context, rate.ID)
+			if err != nil {
+				return err
+			}
+
+			return recordShippingLabel(tx, transaction, shipment, len(shipments), label)
+		}); err != nil {
+			if label == nil {
+				return nil, err
+			}
+
+			fmt.Println("Failed to record purchased shipping label.")
 			fmt.Println(err)
+
+			// The label was paid for, so keep its ID without the status or a retry buys another one.
+			if shipment != nil {
+				shipment.ShippoTransactionID = label.ID
+				if _, err := database.Model(shipment).Column("shippo_transaction_id").WherePK().Update(); err != nil {
+					fmt.Println("Failed to update transaction shipment with shippo transaction id.")
+					fmt.Println(err)
+				}
+			}
+
+			return nil, &core.WrappedError{
+				Message:       fmt.Sprintf("The label %s was purchased, but could not be recorded on the transaction.", label.ID),
+				InternalError: err,
+			}
 		}
 
-		if err := database.Insert(status); err != nil {
-			fmt.Println("Failed to create transaction status with tracking number.")
-			fmt.Println(err)
+		// Payments that were only authorized at checkout are captured once the label is bought, so a
+		// label that could not be bought leaves the payment as it was.
+		if err := captureTransactionPayment(params.Context, transaction); err != nil {
+			return nil, &core.WrappedError{
+				Message:       fmt.Sprintf("The label %s was purchased, but the payment could not be captured. Void the label before shipping.", label.ID),
+				InternalError: err,
+			}
 		}
 
 		if transaction.UserID > 0 {
-			toSend, err := email.NewShippedEmail(label.TrackingURLProvider)
+			toSend, err := email.NewShippedEmail(label.TrackingURL)
 			if err != nil {
 				fmt.Println("Failed create shipped email.")
 				fmt.Println(err)
@@ -112,9 +246,6 @@ var PurchaseShippoLabelField = &graphql.Field{
 			}
 		}
 
-		return map[string]interface{}{
-			"id":       label.ObjectID,
-			"labelUrl": label.LabelURL,
-		}, nil
+		return label, nil
 	},
 }
@@ -0,0 +1,299 @@
+package schema
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+var TransactionNotPaidError = fmt.Errorf("The transaction has not been paid.")
+
+func newTaxDocumentCode() (string, error) {
+	code := make([]byte, 16)
+	if _, err := rand.Read(code); err != nil {
+		return "", &core.WrappedError{
+			Message:       "Could not create tax document code.",
+			InternalError: err,
+		}
+	}
+
+	return hex.EncodeToString(code), nil
+}
+
+// Variants use their own tax code, or their product's when they don't have one.
+func taxCodeForVariant(ctx context.Context, variant *db.ProductVariant) (string, error) {
+	productLoader := ctx.Value("product").(*dataloader.Loader)
+
+	if variant.TaxCode != "" {
+		return variant.TaxCode, nil
+	}
+
+	product, err := productLoader.Load(ctx, dataloaders.IntKey(variant.ProductID))()
+	if err != nil {
+		return "", err
+	}
+
+	return product.(*db.Product).TaxCode, nil
+}
+
+// Calculates the tax for each item of the cart and for shipping. The calculation is saved with the
+// tax provider when a document code is provided.
+func calculateCartTaxes(
+	ctx context.Context,
+	address db.Address,
+	cart dataloaders.CartKey,
+	shipping int,
+	documentCode string,
+	customerCode string) (*services.TaxCalculation, error) {
+	taxProvider := ctx.Value("taxProvider").(services.TaxProvider)
+	productVariantLoader := ctx.Value("productVariant").(*dataloader.Loader)
+
+	ids := make(dataloader.Keys, len(cart))
+	for index, item := range cart {
+		ids[index] = dataloaders.IntKey(item.VariantID)
+	}
+
+	variants, errs := productVariantLoader.LoadMany(ctx, ids)()
+	if errs != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not get variants to calculate taxes for.",
+			InternalError: dataloaders.HandleErrors(errs),
+		}
+	}
+
+	lines := make([]services.TaxLine, len(cart))
+	for index, tempVariant := range variants {
+		variant := tempVariant.(*db.ProductVariant)
+
+		taxCode, err := taxCodeForVariant(ctx, variant)
+		if err != nil {
+			return nil, err
+		}
+
+		lines[index] = services.TaxLine{
+			ID:          variant.ID,
+			Quantity:    cart[index].Quantity,
+			Amount:      variant.Price * cart[index].Quantity,
+			TaxCode:     taxCode,
+			Description: variant.Name,
+		}
+	}
+
+	return taxProvider.CalculateTax(ctx, services.TaxRequest{
+		DocumentCode: documentCode,
+		CustomerCode: customerCode,
+		Address:      address,
+		Lines:        lines,
+		Shipping:     shipping,
+	})
+}
+
+func customerCodeForClaims(claims *auth.Claims) string {
+	if claims == nil {
+		return ""
+	}
+
+	return strconv.Itoa(claims.ID)
+}
+
+var TaxDetailType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TaxDetail",
+	Fields: graphql.Fields{
+		"name": &graphql.Field{
+			Type: graphql.String,
+		},
+		"type": &graphql.Field{
+			Type: graphql.String,
+		},
+		"rate": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"tax": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+})
+
+var CartTaxLineType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "CartTaxLine",
+	Fields: graphql.Fields{
+		"variantId": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				line := params.Source.(*services.TaxLineResult)
+
+				return line.ID, nil
+			},
+		},
+		"tax": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"details": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(TaxDetailType)),
+		},
+	},
+})
+
+var CartTaxesType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "CartTaxes",
+	Description: "The tax for each item of a cart and for shipping.",
+	Fields: graphql.Fields{
+		"totalTax": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"lines": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(CartTaxLineType)),
+		},
+		"shippingTax": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shippingDetails": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(TaxDetailType)),
+		},
+	},
+})
+
+var CartTaxesField = &graphql.Field{
+	Type:        CartTaxesType,
+	Description: "Calculates the taxes checkout charges for the provided variants shipped to the address with the selected shipping rates.",
+	Args: graphql.FieldConfigArgument{
+		"address": &graphql.ArgumentConfig{
+			Type:        graphql.NewNonNull(AddressInputSchema),
+			Description: "The address the order ships to.",
+		},
+		"variants": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.NewList(
+				graphql.NewNonNull(CartInputSchema),
+			)),
+		},
+		"shippingRateIds": &graphql.ArgumentConfig{
+			Type: graphql.NewList(graphql.NewNonNull(graphql.String)),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		claims := params.Context.Value("claims").(*auth.Claims)
+
+		address := db.Address{}
+		if err := ConvertObject(params.Args["address"], &address); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not convert address argument.",
+				InternalError: err,
+			}
+		}
+
+		cart := dataloaders.CartKey{}
+		if err := ConvertObject(params.Args["variants"], &cart); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not convert variants argument.",
+				InternalError: err,
+			}
+		}
+
+		shippingRateIDs := []string{}
+		if shippingRateIDsTemp, ok := params.Args["shippingRateIds"]; ok {
+			if err := ConvertObject(shippingRateIDsTemp, &shippingRateIDs); err != nil {
+				return nil, &core.WrappedError{
+					Message:       "Could not convert shippingRateIds argument.",
+					InternalError: err,
+				}
+			}
+		}
+
+		shipping := 0
+		for _, shippingRateID := range shippingRateIDs {
+			estimation, err := retrieveShippingEstimation(params.Context, shippingRateID)
+			if err != nil {
+				return nil, err
+			}
+
+			shipping += estimation.Price
+		}
+
+		return calculateCartTaxes(params.Context, address, cart, shipping, "", customerCodeForClaims(claims))
+	},
+}
+
+// Commits the taxes saved for a paid transaction. Failures are logged, and an admin can retry them
+// with commitTransactionTaxes.
+func commitTransactionTaxes(ctx context.Context, transaction *db.Transaction) error {
+	database := ctx.Value("database").(*pg.DB)
+	taxProvider := ctx.Value("taxProvider").(services.TaxProvider)
+
+	if transaction.TaxDocumentCode == "" || transaction.TaxCommitted {
+		return nil
+	}
+
+	if err := taxProvider.CommitTax(ctx, transaction.TaxDocumentCode); err != nil {
+		fmt.Println("Failed to commit taxes for transaction.")
+		fmt.Println(err)
+		return err
+	}
+
+	transaction.TaxCommitted = true
+	if _, err := database.
+		Model(transaction).
+		Column("tax_committed", "updated_at").
+		WherePK().
+		Update(); err != nil {
+		fmt.Println("Failed to mark transaction taxes as committed.")
+		fmt.Println(err)
+		return &core.WrappedError{
+			Message:       "Could not update transaction.",
+			InternalError: err,
+		}
+	}
+
+	return nil
+}
+
+var CommitTransactionTaxesField = &graphql.Field{
+	Type:        TransactionType,
+	Description: "Retry committing the taxes of a paid transaction to the tax provider.",
+	Args: graphql.FieldConfigArgument{
+		"transactionId": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		transactionLoader := params.Context.Value("transaction").(*dataloader.Loader)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		tempTransaction, err := transactionLoader.Load(params.Context, dataloaders.IntKey(params.Args["transactionId"].(int)))()
+		if err != nil {
+			return nil, err
+		}
+		transaction := tempTransaction.(*db.Transaction)
+
+		if transaction.BraintreeID == "" {
+			return nil, TransactionNotPaidError
+		}
+		if isPaymentAuthorized(transaction) {
+			return nil, TransactionNotCapturedError
+		}
+
+		if err := commitTransactionTaxes(params.Context, transaction); err != nil {
+			return nil, err
+		}
+
+		return transaction, nil
+	},
+}
@@ -0,0 +1,304 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+var InvalidTaxRuleError = fmt.Errorf("Tax rules need a name, a country and a rate between 0 and 1.")
+
+func validateTaxRule(rule *db.TaxRule) error {
+	if rule.Name == "" || rule.Country == "" || rule.Rate < 0 || rule.Rate > 1 {
+		return InvalidTaxRuleError
+	}
+
+	return nil
+}
+
+var TaxRuleType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "TaxRule",
+	Description: "A tax rate the local tax provider charges. Every rule that matches an address applies.",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"name": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"type": &graphql.Field{
+			Type: graphql.String,
+		},
+		"country": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"region": &graphql.Field{
+			Type: graphql.String,
+		},
+		"postalCodePrefix": &graphql.Field{
+			Type: graphql.String,
+		},
+		"rate": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"exemptTaxCodes": &graphql.Field{
+			Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+			Description: "Tax codes of goods the rule does not apply to.",
+		},
+		"taxesShipping": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Boolean),
+			Description: "If the rule also applies to shipping.",
+		},
+	},
+})
+
+var TaxRulesField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(TaxRuleType)),
+	Description: "The tax rules used by the local tax provider.",
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		rules := []*db.TaxRule{}
+		if err := database.
+			Model(&rules).
+			OrderExpr("tax_rule.country ASC, tax_rule.region ASC, tax_rule.postal_code_prefix ASC, tax_rule.id ASC").
+			Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not get tax rules.",
+				InternalError: err,
+			}
+		}
+
+		return rules, nil
+	},
+}
+
+var CreateTaxRuleInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateTaxRuleInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"type": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"country": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"region": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"postalCodePrefix": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"rate": &graphql.InputObjectFieldConfig{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"exemptTaxCodes": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+			Description: "Tax codes of goods the rule does not apply to.",
+		},
+		"taxesShipping": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Boolean,
+			Description: "If the rule also applies to shipping.",
+		},
+	},
+})
+
+var CreateTaxRuleField = &graphql.Field{
+	Type:        TaxRuleType,
+	Description: "Create a tax rule. Leave the region and postal code prefix empty to match the whole country.",
+	Args: graphql.FieldConfigArgument{
+		"input": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(CreateTaxRuleInputSchema),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		rule := db.TaxRule{}
+		if err := ConvertObject(params.Args["input"], &rule); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not convert input.",
+				InternalError: err,
+			}
+		}
+		rule.Name = strings.TrimSpace(rule.Name)
+		rule.Type = strings.TrimSpace(rule.Type)
+		rule.Country = strings.TrimSpace(rule.Country)
+		rule.Region = strings.TrimSpace(rule.Region)
+		rule.PostalCodePrefix = strings.TrimSpace(rule.PostalCodePrefix)
+
+		if err := validateTaxRule(&rule); err != nil {
+			return nil, err
+		}
+
+		if err := database.Insert(&rule); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not create tax rule.",
+				InternalError: err,
+			}
+		}
+
+		return &rule, nil
+	},
+}
+
+var UpdateTaxRuleInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "UpdateTaxRuleInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"type": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"rate": &graphql.InputObjectFieldConfig{
+			Type: graphql.Float,
+		},
+		"exemptTaxCodes": &graphql.InputObjectFieldConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+			Description: "Tax codes of goods the rule does not apply to.",
+		},
+		"taxesShipping": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Boolean,
+			Description: "If the rule also applies to shipping.",
+		},
+	},
+})
+
+var UpdateTaxRuleField = &graphql.Field{
+	Type:        TaxRuleType,
+	Description: "Update the name, type, rate, exemptions or shipping taxability of a tax rule.",
+	Args: graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"input": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(UpdateTaxRuleInputSchema),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		id := params.Args["id"].(int)
+		input := params.Args["input"].(map[string]interface{})
+		name := OptionalString(input, "name")
+		ruleType := OptionalString(input, "type")
+		rate := OptionalFloat(input, "rate")
+		taxesShipping, hasTaxesShipping := input["taxesShipping"].(bool)
+
+		rule := db.TaxRule{ID: id}
+		if err := database.Select(&rule); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not find tax rule to update.",
+				InternalError: err,
+			}
+		}
+
+		if name != nil {
+			rule.Name = strings.TrimSpace(*name)
+		}
+		if ruleType != nil {
+			rule.Type = strings.TrimSpace(*ruleType)
+		}
+		if rate != nil {
+			rule.Rate = *rate
+		}
+		if exemptTaxCodes, ok := input["exemptTaxCodes"]; ok {
+			rule.ExemptTaxCodes = []string{}
+			if err := ConvertObject(exemptTaxCodes, &rule.ExemptTaxCodes); err != nil {
+				return nil, &core.WrappedError{
+					Message:       "Could not convert exemptTaxCodes.",
+					InternalError: err,
+				}
+			}
+		}
+		if hasTaxesShipping {
+			rule.TaxesShipping = taxesShipping
+		}
+
+		if err := validateTaxRule(&rule); err != nil {
+			return nil, err
+		}
+
+		if err := database.Update(&rule); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not update tax rule.",
+				InternalError: err,
+			}
+		}
+
+		return &rule, nil
+	},
+}
+
+var RemoveTaxRuleField = &graphql.Field{
+	Type:        TaxRuleType,
+	Description: "Remove a tax rule.",
+	Args: graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		id := params.Args["id"].(int)
+
+		toDelete := db.TaxRule{}
+		if err := database.Model(&toDelete).Where("id = ?", id).Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not retrieve tax rule to remove.",
+				InternalError: err,
+			}
+		}
+
+		if err := database.Delete(&toDelete); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not remove tax rule.",
+				InternalError: err,
+			}
+		}
+
+		return &toDelete, nil
+	},
+}
@@ -0,0 +1,175 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/graph-gophers/dataloader"
+
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/email"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+// Finds the transaction a tracking number was shipped with, or the transaction of the label when
+// the tracking number was not recorded.
+func findTrackedTransaction(database *pg.DB, event *services.TrackingEvent) (int, error) {
+	shipped := db.TransactionStatus{}
+	err := database.
+		Model(&shipped).
+		Where("tracking_id = ?", event.TrackingNumber).
+		Where("status = ?", TransactionStatusShipped).
+		OrderExpr("id DESC").
+		Limit(1).
+		Select()
+	if err == nil {
+		return shipped.TransactionID, nil
+	}
+	if err != pg.ErrNoRows || event.LabelID == "" {
+		return 0, err
+	}
+
+	shipment := db.TransactionShipment{}
+	err = database.
+		Model(&shipment).
+		Where("shippo_transaction_id = ?", event.LabelID).
+		Limit(1).
+		Select()
+	if err == nil {
+		return shipment.TransactionID, nil
+	}
+	if err != pg.ErrNoRows {
+		return 0, err
+	}
+
+	transaction := db.Transaction{}
+	if err := database.
+		Model(&transaction).
+		Where("shippo_transaction_id = ?", event.LabelID).
+		Limit(1).
+		Select(); err != nil {
+		return 0, err
+	}
+
+	return transaction.ID, nil
+}
+
+func handleTrackingEvent(ctx context.Context, event *services.TrackingEvent) error {
+	database := ctx.Value("database").(*pg.DB)
+	transactionLoader := ctx.Value("transaction").(*dataloader.Loader)
+	userLoader := ctx.Value("user").(*dataloader.Loader)
+	emailClient := ctx.Value("email").(email.Client)
+	baseUrl := ctx.Value("baseUrl").(string)
+	sendDeliveryEmails := ctx.Value("sendDeliveryEmails").(bool)
+
+	if event.Status == "" || event.TrackingNumber == "" {
+		return nil
+	}
+
+	transactionID, err := findTrackedTransaction(database, event)
+	if err == pg.ErrNoRows {
+		fmt.Println("Received tracking update for unknown tracking number " + event.TrackingNumber + ".")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Shippo sends an update for every scan, so only changes of status are recorded.
+	latest := db.TransactionStatus{}
+	err = database.
+		Model(&latest).
+		Where("transaction_id = ?", transactionID).
+		Where("tracking_id = ?", event.TrackingNumber).
+		OrderExpr("id DESC").
+		Limit(1).
+		Select()
+	if err != nil && err != pg.ErrNoRows {
+		return err
+	}
+	if err == nil && latest.Status == event.Status {
+		return nil
+	}
+
+	err = appendTransactionStatus(database, &db.TransactionStatus{
+		CreatedAt:     event.Timestamp,
+		TransactionID: transactionID,
+		Status:        event.Status,
+		Carrier:       event.Carrier,
+		TrackingID:    event.TrackingNumber,
+	})
+	// Updates for cancelled orders and for packages behind the rest of the order are acknowledged so
+	// shippo stops sending them.
+	if err == InvalidTransactionStatusTransitionError {
+		fmt.Println("Ignored tracking update for transaction", transactionID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if event.Status != services.TrackingStatusDelivered || !sendDeliveryEmails {
+		return nil
+	}
+
+	tempTransaction, err := transactionLoader.Load(ctx, dataloaders.IntKey(transactionID))()
+	if err != nil {
+		fmt.Println("Failed to find delivered transaction.")
+		fmt.Println(err)
+		return nil
+	}
+	transaction := tempTransaction.(*db.Transaction)
+
+	if transaction.UserID > 0 {
+		tempUser, err := userLoader.Load(ctx, dataloaders.IntKey(transaction.UserID))()
+		if err != nil {
+			fmt.Println("Failed to find user to email delivered order to.")
+			fmt.Println(err)
+			return nil
+		}
+
+		toSend, err := email.NewDeliveredEmail(baseUrl, transaction.ID)
+		if err != nil {
+			fmt.Println("Failed create delivered email.")
+			fmt.Println(err)
+		} else if err := emailClient.SendMail(tempUser.(*db.User).Email, "Your order has been delivered.", toSend); err != nil {
+			fmt.Println("Failed to send delivered email.")
+			fmt.Println(err)
+		}
+	}
+
+	return nil
+}
+
+// Receives the shipping provider's tracking webhooks.
+func HandleTrackingWebhook(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	shippingProvider := ctx.Value("shippingProvider").(services.ShippingProvider)
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	event, err := shippingProvider.ParseTrackingWebhook(r)
+	if err != nil {
+		fmt.Println("Rejected tracking webhook.")
+		fmt.Println(err)
+		if err == services.TrackingWebhookTokenError {
+			w.WriteHeader(http.StatusUnauthorized)
+		} else {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		return
+	}
+
+	if err := handleTrackingEvent(ctx, event); err != nil {
+		fmt.Println("Failed to handle tracking webhook.")
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,365 @@
+package schema
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+// The number of transactions read from the database at a time while exporting.
+const transactionExportBatchSize = 100
+
+var transactionExportColumns = []string{
+	"id",
+	"created_at",
+	"email",
+	"braintree_id",
+	"payment_status",
+	"subtotal",
+	"taxes",
+	"shipping",
+	"shipping_tax",
+	"total",
+	"billing_name",
+	"billing_address",
+	"shipping_name",
+	"shipping_address",
+	"line_items",
+}
+
+type exportedLineItem struct {
+	ProductVariantID int    `json:"productVariantId"`
+	Name             string `json:"name"`
+	Price            int    `json:"price"`
+	Quantity         int    `json:"quantity"`
+	Tax              int    `json:"tax"`
+}
+
+type exportedAddress struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	Line3      string `json:"line3,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
+// A transaction as it is written to an export. Amounts are in cents.
+type exportedTransaction struct {
+	ID              int                 `json:"id"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	Email           string              `json:"email"`
+	BraintreeID     string              `json:"braintreeId"`
+	PaymentStatus   string              `json:"paymentStatus"`
+	Subtotal        int                 `json:"subtotal"`
+	Taxes           int                 `json:"taxes"`
+	Shipping        int                 `json:"shipping"`
+	ShippingTax     int                 `json:"shippingTax"`
+	Total           int                 `json:"total"`
+	BillingAddress  *exportedAddress    `json:"billingAddress"`
+	ShippingAddress *exportedAddress    `json:"shippingAddress"`
+	LineItems       []*exportedLineItem `json:"lineItems"`
+}
+
+func exportAddress(address *db.Address) *exportedAddress {
+	if address == nil {
+		return nil
+	}
+
+	return &exportedAddress{
+		Name:       address.Name,
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		Line3:      address.Line3,
+		City:       address.City,
+		Region:     address.Region,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+	}
+}
+
+func exportTransaction(transaction *db.Transaction) *exportedTransaction {
+	result := &exportedTransaction{
+		ID:            transaction.ID,
+		CreatedAt:     transaction.CreatedAt,
+		BraintreeID:   transaction.BraintreeID,
+		PaymentStatus: transaction.PaymentStatus,
+		Subtotal:      transaction.Subtotal,
+		Taxes:         transaction.Taxes,
+		Shipping:      transaction.Shipping,
+		ShippingTax:   transaction.ShippingTax,
+		Total:         transaction.Total,
+		LineItems:     []*exportedLineItem{},
+	}
+
+	if transaction.User != nil {
+		result.Email = transaction.User.Email
+	}
+
+	if transaction.Addresses != nil {
+		result.BillingAddress = exportAddress(transaction.Addresses.BillingAddress)
+		result.ShippingAddress = exportAddress(transaction.Addresses.ShippingAddress)
+	}
+
+	for _, lineItem := range transaction.LineItems {
+		exported := &exportedLineItem{
+			ProductVariantID: lineItem.ProductVariantID,
+			Price:            lineItem.Price,
+			Quantity:         lineItem.Quantity,
+			Tax:              lineItem.Tax,
+		}
+		if lineItem.ProductVariant != nil {
+			exported.Name = lineItem.ProductVariant.Name
+		}
+		result.LineItems = append(result.LineItems, exported)
+	}
+
+	return result
+}
+
+func (address *exportedAddress) String() string {
+	if address == nil {
+		return ""
+	}
+
+	parts := []string{}
+	for _, part := range []string{
+		address.Line1, address.Line2, address.Line3, address.City, address.Region, address.PostalCode, address.Country,
+	} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (address *exportedAddress) name() string {
+	if address == nil {
+		return ""
+	}
+
+	return address.Name
+}
+
+// The columns of the transaction in the order of transactionExportColumns. Line items are written
+// as "quantity x name (variant id) @ price" and separated by semicolons.
+func (transaction *exportedTransaction) record() []string {
+	lineItems := make([]string, len(transaction.LineItems))
+	for index, lineItem := range transaction.LineItems {
+		lineItems[index] = fmt.Sprintf("%d x %s (%d) @ %d", lineItem.Quantity, lineItem.Name, lineItem.ProductVariantID, lineItem.Price)
+	}
+
+	return []string{
+		strconv.Itoa(transaction.ID),
+		transaction.CreatedAt.Format(time.RFC3339),
+		transaction.Email,
+		transaction.BraintreeID,
+		transaction.PaymentStatus,
+		strconv.Itoa(transaction.Subtotal),
+		strconv.Itoa(transaction.Taxes),
+		strconv.Itoa(transaction.Shipping),
+		strconv.Itoa(transaction.ShippingTax),
+		strconv.Itoa(transaction.Total),
+		transaction.BillingAddress.name(),
+		transaction.BillingAddress.String(),
+		transaction.ShippingAddress.name(),
+		transaction.ShippingAddress.String(),
+		strings.Join(lineItems, "; "),
+	}
+}
+
+// Parses a date of the export range, either a day or a full timestamp.
+func parseExportDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// Reads the transactions of the filter in batches, in the order they were placed, and calls write
+// with each batch.
+func eachExportedTransaction(database *pg.DB, filter transactionFilter, write func(transactions []*db.Transaction) error) error {
+	lastID := 0
+	for {
+		transactions := []*db.Transaction{}
+		query := database.
+			Model(&transactions).
+			Relation("User").
+			Relation("Addresses").
+			Relation("Addresses.BillingAddress").
+			Relation("Addresses.ShippingAddress").
+			Relation("LineItems", func(query *orm.Query) (*orm.Query, error) {
+				return query.OrderExpr("transaction_line_item.id ASC"), nil
+			}).
+			Relation("LineItems.ProductVariant").
+			Where("transaction.id > ?", lastID)
+		applyTransactionFilter(query, filter)
+
+		if err := query.
+			OrderExpr("transaction.id ASC").
+			Limit(transactionExportBatchSize).
+			Select(); err != nil {
+			return err
+		}
+
+		if len(transactions) == 0 {
+			return nil
+		}
+
+		if err := write(transactions); err != nil {
+			return err
+		}
+
+		if len(transactions) < transactionExportBatchSize {
+			return nil
+		}
+		lastID = transactions[len(transactions)-1].ID
+	}
+}
+
+// Streams the orders placed in a date range as CSV or as JSON lines. The range is given with the
+// from and to query parameters, and defaults to the current month. An optional status limits the
+// export to orders in that status.
+func HandleTransactionExport(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	database := ctx.Value("database").(*pg.DB)
+
+	claims, _ := ctx.Value("claims").(*auth.Claims)
+	if claims == nil {
+		http.Error(w, auth.NotAuthenticatedError.Error(), http.StatusUnauthorized)
+		return
+	}
+	if claims.Role != "ADMIN" {
+		http.Error(w, auth.NotAuthorizedError.Error(), http.StatusForbidden)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	if value := query.Get("from"); value != "" {
+		date, err := parseExportDate(value)
+		if err != nil {
+			http.Error(w, "The from date is not valid.", http.StatusBadRequest)
+			return
+		}
+		from = date
+	}
+	if value := query.Get("to"); value != "" {
+		date, err := parseExportDate(value)
+		if err != nil {
+			http.Error(w, "The to date is not valid.", http.StatusBadRequest)
+			return
+		}
+		to = date
+	}
+	if !from.Before(to) {
+		http.Error(w, InvalidDateRangeError.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := transactionFilter{
+		PlacedAfter:  &from,
+		PlacedBefore: &to,
+	}
+	if status := query.Get("status"); status != "" {
+		if _, ok := transactionStatuses[status]; !ok || transactionStatusIsPayment(status) {
+			http.Error(w, InvalidTransactionStatusError.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Status = &status
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		http.Error(w, "The format must be csv or json.", http.StatusBadRequest)
+		return
+	}
+
+	// Exports of large stores take longer than the server's write timeout, which would cut the file
+	// off without an error.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		fmt.Println("Failed to clear the write deadline of the transaction export.")
+		fmt.Println(err)
+	}
+
+	filename := fmt.Sprintf("orders-%s-%s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	flusher, _ := w.(http.Flusher)
+
+	var write func(transactions []*db.Transaction) error
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+".csv\"")
+
+		writer := csv.NewWriter(w)
+		if err := writer.Write(transactionExportColumns); err != nil {
+			fmt.Println("Failed to write transaction export.")
+			fmt.Println(err)
+			return
+		}
+
+		write = func(transactions []*db.Transaction) error {
+			for _, transaction := range transactions {
+				if err := writer.Write(exportTransaction(transaction).record()); err != nil {
+					return err
+				}
+			}
+
+			writer.Flush()
+			return writer.Error()
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+".jsonl\"")
+
+		encoder := json.NewEncoder(w)
+		write = func(transactions []*db.Transaction) error {
+			for _, transaction := range transactions {
+				if err := encoder.Encode(exportTransaction(transaction)); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
+
+	if err := eachExportedTransaction(database, filter, func(transactions []*db.Transaction) error {
+		if err := write(transactions); err != nil {
+			return err
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}); err != nil {
+		// The response has already started, so the export is cut short instead.
+		fmt.Println("Failed to export transactions.")
+		fmt.Println(err)
+	}
+}
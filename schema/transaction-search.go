@@ -0,0 +1,226 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/auth"
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+const (
+	TransactionSortNewest    = "NEWEST"
+	TransactionSortOldest    = "OLDEST"
+	TransactionSortTotalDesc = "TOTAL_DESC"
+	TransactionSortTotalAsc  = "TOTAL_ASC"
+	TransactionSortUpdated   = "RECENTLY_UPDATED"
+)
+
+var transactionSortExpressions = map[string]string{
+	TransactionSortNewest:    "transaction.created_at DESC NULLS LAST, transaction.id DESC",
+	TransactionSortOldest:    "transaction.created_at ASC NULLS FIRST, transaction.id ASC",
+	TransactionSortTotalDesc: "transaction.total DESC, transaction.id DESC",
+	TransactionSortTotalAsc:  "transaction.total ASC, transaction.id DESC",
+	TransactionSortUpdated:   "transaction.updated_at DESC NULLS LAST, transaction.id DESC",
+}
+
+var TransactionSortEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "TransactionSort",
+	Values: graphql.EnumValueConfigMap{
+		TransactionSortNewest: &graphql.EnumValueConfig{
+			Value:       TransactionSortNewest,
+			Description: "The most recent orders first.",
+		},
+		TransactionSortOldest: &graphql.EnumValueConfig{
+			Value:       TransactionSortOldest,
+			Description: "The oldest orders first.",
+		},
+		TransactionSortTotalDesc: &graphql.EnumValueConfig{
+			Value:       TransactionSortTotalDesc,
+			Description: "The largest totals first.",
+		},
+		TransactionSortTotalAsc: &graphql.EnumValueConfig{
+			Value:       TransactionSortTotalAsc,
+			Description: "The smallest totals first.",
+		},
+		TransactionSortUpdated: &graphql.EnumValueConfig{
+			Value:       TransactionSortUpdated,
+			Description: "The most recently updated orders first.",
+		},
+	},
+})
+
+var TransactionFilterInputSchema = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "TransactionFilterInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"email": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "The email of the customer, ignoring case.",
+		},
+		"status": &graphql.InputObjectFieldConfig{
+			Type:        TransactionStatusEnum,
+			Description: "The current order status.",
+		},
+		"placedAfter": &graphql.InputObjectFieldConfig{
+			Type: graphql.DateTime,
+		},
+		"placedBefore": &graphql.InputObjectFieldConfig{
+			Type: graphql.DateTime,
+		},
+		"minTotal": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "The smallest total in cents.",
+		},
+		"maxTotal": &graphql.InputObjectFieldConfig{
+			Type:        graphql.Int,
+			Description: "The largest total in cents.",
+		},
+		"trackingNumber": &graphql.InputObjectFieldConfig{
+			Type:        graphql.String,
+			Description: "The tracking number of any shipment of the order.",
+		},
+		"braintreeId": &graphql.InputObjectFieldConfig{
+			Type: graphql.String,
+		},
+		"disputed": &graphql.InputObjectFieldConfig{
+			Type: graphql.Boolean,
+		},
+	},
+})
+
+type transactionFilter struct {
+	Email          *string
+	Status         *string
+	PlacedAfter    *time.Time
+	PlacedBefore   *time.Time
+	MinTotal       *int
+	MaxTotal       *int
+	TrackingNumber *string
+	BraintreeID    *string
+	Disputed       *bool
+}
+
+// Adds the conditions of the filter to a query of transactions.
+func applyTransactionFilter(query *orm.Query, filter transactionFilter) {
+	if filter.Email != nil {
+		query.Where("transaction.user_id IN (SELECT customer.id FROM users AS customer WHERE lower(customer.email) = lower(?))", *filter.Email)
+	}
+
+	if filter.Status != nil {
+		query.Where(`(SELECT latest.status FROM transaction_statuses AS latest
+			WHERE latest.transaction_id = transaction.id AND latest.status IN (?)
+			ORDER BY latest.id DESC LIMIT 1) = ?`, pg.In(orderStatuses()), *filter.Status)
+	}
+
+	if filter.PlacedAfter != nil {
+		query.Where("transaction.created_at >= ?", *filter.PlacedAfter)
+	}
+
+	if filter.PlacedBefore != nil {
+		query.Where("transaction.created_at < ?", *filter.PlacedBefore)
+	}
+
+	if filter.MinTotal != nil {
+		query.Where("transaction.total >= ?", *filter.MinTotal)
+	}
+
+	if filter.MaxTotal != nil {
+		query.Where("transaction.total <= ?", *filter.MaxTotal)
+	}
+
+	if filter.TrackingNumber != nil {
+		query.Where(`EXISTS (SELECT 1 FROM transaction_statuses AS tracked
+			WHERE tracked.transaction_id = transaction.id AND tracked.tracking_id = ?)`, *filter.TrackingNumber)
+	}
+
+	if filter.BraintreeID != nil {
+		query.Where("transaction.braintree_id = ?", *filter.BraintreeID)
+	}
+
+	if filter.Disputed != nil {
+		query.Where("transaction.disputed = ?", *filter.Disputed)
+	}
+}
+
+var TransactionsField = &graphql.Field{
+	Type:        graphql.NewList(TransactionType),
+	Description: "Search and paginate through the transactions.",
+	Args: graphql.FieldConfigArgument{
+		"skip": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+		"limit": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+		"filter": &graphql.ArgumentConfig{
+			Type: TransactionFilterInputSchema,
+		},
+		"sort": &graphql.ArgumentConfig{
+			Type:         TransactionSortEnum,
+			DefaultValue: TransactionSortNewest,
+		},
+	},
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		database := params.Context.Value("database").(*pg.DB)
+		transactionLoader := params.Context.Value("transaction").(*dataloader.Loader)
+
+		claims := params.Context.Value("claims").(*auth.Claims)
+		if claims == nil {
+			return nil, auth.NotAuthenticatedError
+		}
+		if claims.Role != "ADMIN" {
+			return nil, auth.NotAuthorizedError
+		}
+
+		skip, _ := params.Args["skip"].(int)
+		limit, _ := params.Args["limit"].(int)
+
+		if skip < 0 {
+			skip = 0
+		}
+
+		if limit <= 0 {
+			limit = 20
+		}
+
+		filter := transactionFilter{}
+		if input, ok := params.Args["filter"]; ok && input != nil {
+			if err := ConvertObject(input, &filter); err != nil {
+				return nil, err
+			}
+		}
+
+		sortArg, _ := params.Args["sort"].(string)
+		sort, ok := transactionSortExpressions[sortArg]
+		if !ok {
+			sort = transactionSortExpressions[TransactionSortNewest]
+		}
+
+		results := []*db.Transaction{}
+		query := database.Model(&results)
+		applyTransactionFilter(query, filter)
+
+		if err := query.
+			OrderExpr(sort).
+			Offset(skip).
+			Limit(limit).
+			Select(); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Failed to search transactions.",
+				InternalError: err,
+			}
+		}
+
+		for _, result := range results {
+			transactionLoader.Prime(params.Context, dataloaders.IntKey(result.ID), result)
+		}
+
+		return results, nil
+	},
+}
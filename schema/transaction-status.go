@@ -0,0 +1,351 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/go-pg/pg/v9/orm"
+	"github.com/graph-gophers/dataloader"
+	"github.com/graphql-go/graphql"
+	core "github.com/jacob-ebey/graphql-core"
+
+	"github.com/jacob-ebey/golang-ecomm/dataloaders"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	"github.com/jacob-ebey/golang-ecomm/services"
+)
+
+// Order statuses move the order through its lifecycle.
+const (
+	TransactionStatusReceived       = "RECEIVED"
+	TransactionStatusAuthorized     = "AUTHORIZED"
+	TransactionStatusShipped        = "SHIPPED"
+	TransactionStatusInTransit      = services.TrackingStatusInTransit
+	TransactionStatusOutForDelivery = services.TrackingStatusOutForDelivery
+	TransactionStatusDelivered      = services.TrackingStatusDelivered
+	TransactionStatusReturned       = services.TrackingStatusReturned
+	TransactionStatusCancelled      = "CANCELLED"
+)
+
+// Payment statuses record what happened to the payment and leave the order status as it was.
+const (
+	TransactionStatusCaptured             = "CAPTURED"
+	TransactionStatusAuthorizationExpired = "AUTHORIZATION_EXPIRED"
+	TransactionStatusCaptureFailed        = "CAPTURE_FAILED"
+	TransactionStatusSettled              = "SETTLED"
+	TransactionStatusSettlementDeclined   = "SETTLEMENT_DECLINED"
+	TransactionStatusRefunded             = "REFUNDED"
+	TransactionStatusPartiallyRefunded    = "PARTIALLY_REFUNDED"
+	TransactionStatusDisputed             = "DISPUTED"
+	TransactionStatusDisputeWon           = "DISPUTE_WON"
+	TransactionStatusDisputeLost          = "DISPUTE_LOST"
+)
+
+var InvalidTransactionStatusError = fmt.Errorf("The transaction status is not valid.")
+var InvalidTransactionStatusTransitionError = fmt.Errorf("The transaction can not move to that status from its current status.")
+
+type transactionStatusDefinition struct {
+	Description string
+	Payment     bool
+	// The order statuses an order can move to from this one.
+	Next []string
+}
+
+var transactionStatuses = map[string]transactionStatusDefinition{
+	TransactionStatusReceived: {
+		Description: "The order was placed and paid for.",
+		Next:        []string{TransactionStatusAuthorized, TransactionStatusShipped, TransactionStatusCancelled},
+	},
+	TransactionStatusAuthorized: {
+		Description: "The order was placed and its payment authorized. The payment is captured when it ships.",
+		Next:        []string{TransactionStatusShipped, TransactionStatusCancelled},
+	},
+	// Tracking updates only move a shipped order forward, and updates for a package that is behind
+	// another package of the order are ignored. Buying the label of another shipment moves the order
+	// back to shipped, as the order is only as far along as that package.
+	TransactionStatusShipped: {
+		Description: "A label was purchased for a shipment of the order.",
+		Next: []string{
+			TransactionStatusShipped,
+			TransactionStatusInTransit,
+			TransactionStatusOutForDelivery,
+			TransactionStatusDelivered,
+			TransactionStatusReturned,
+			TransactionStatusCancelled,
+		},
+	},
+	TransactionStatusInTransit: {
+		Description: "A package of the order is on its way.",
+		Next: []string{
+			TransactionStatusShipped,
+			TransactionStatusInTransit,
+			TransactionStatusOutForDelivery,
+			TransactionStatusDelivered,
+			TransactionStatusReturned,
+			TransactionStatusCancelled,
+		},
+	},
+	TransactionStatusOutForDelivery: {
+		Description: "A package of the order is out for delivery.",
+		Next: []string{
+			TransactionStatusShipped,
+			TransactionStatusOutForDelivery,
+			TransactionStatusDelivered,
+			TransactionStatusReturned,
+			TransactionStatusCancelled,
+		},
+	},
+	TransactionStatusDelivered: {
+		Description: "A package of the order was delivered.",
+		Next:        []string{TransactionStatusShipped, TransactionStatusDelivered, TransactionStatusReturned},
+	},
+	TransactionStatusReturned: {
+		Description: "A package of the order was returned to the sender.",
+		Next:        []string{TransactionStatusReturned, TransactionStatusCancelled},
+	},
+	TransactionStatusCancelled: {
+		Description: "The order was cancelled.",
+	},
+	TransactionStatusCaptured: {
+		Description: "The authorized payment was captured.",
+		Payment:     true,
+	},
+	TransactionStatusAuthorizationExpired: {
+		Description: "The authorization expired before the payment was captured.",
+		Payment:     true,
+	},
+	TransactionStatusCaptureFailed: {
+		Description: "The authorized payment could not be captured.",
+		Payment:     true,
+	},
+	TransactionStatusSettled: {
+		Description: "The payment settled.",
+		Payment:     true,
+	},
+	TransactionStatusSettlementDeclined: {
+		Description: "The payment was declined when it settled.",
+		Payment:     true,
+	},
+	TransactionStatusRefunded: {
+		Description: "The whole payment was refunded.",
+		Payment:     true,
+	},
+	TransactionStatusPartiallyRefunded: {
+		Description: "Part of the payment was refunded.",
+		Payment:     true,
+	},
+	TransactionStatusDisputed: {
+		Description: "The customer opened a dispute for the payment.",
+		Payment:     true,
+	},
+	TransactionStatusDisputeWon: {
+		Description: "The dispute was decided for the store.",
+		Payment:     true,
+	},
+	TransactionStatusDisputeLost: {
+		Description: "The dispute was decided for the customer.",
+		Payment:     true,
+	},
+}
+
+func orderStatuses() []string {
+	statuses := []string{}
+	for status, definition := range transactionStatuses {
+		if !definition.Payment {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// Checks that an order in the current status can move to the next status. Orders without a status
+// can only be received, and payment statuses can be recorded at any time.
+func validateTransactionStatusTransition(current string, next string) error {
+	definition, ok := transactionStatuses[next]
+	if !ok {
+		return InvalidTransactionStatusError
+	}
+	if definition.Payment {
+		return nil
+	}
+
+	if current == "" {
+		if next == TransactionStatusReceived {
+			return nil
+		}
+
+		return InvalidTransactionStatusTransitionError
+	}
+
+	for _, allowed := range transactionStatuses[current].Next {
+		if allowed == next {
+			return nil
+		}
+	}
+
+	return InvalidTransactionStatusTransitionError
+}
+
+// The order status of a transaction, ignoring payment statuses. Empty when it has none.
+func currentTransactionStatus(database orm.DB, transactionID int) (string, error) {
+	status := db.TransactionStatus{}
+	if err := database.
+		Model(&status).
+		Where("transaction_id = ?", transactionID).
+		WhereIn("status IN (?)", orderStatuses()).
+		OrderExpr("id DESC").
+		Limit(1).
+		Select(); err != nil {
+		if err == pg.ErrNoRows {
+			return "", nil
+		}
+
+		return "", &core.WrappedError{
+			Message:       "Could not get transaction status.",
+			InternalError: err,
+		}
+	}
+
+	return status.Status, nil
+}
+
+// Adds a status to the history of a transaction when the order can move to it. The transaction is
+// locked so statuses appended at the same time are checked against each other.
+func appendTransactionStatus(database orm.DB, status *db.TransactionStatus) error {
+	switch database := database.(type) {
+	case *pg.Tx:
+		return appendLockedTransactionStatus(database, status)
+	case *pg.DB:
+		return database.RunInTransaction(func(tx *pg.Tx) error {
+			return appendLockedTransactionStatus(tx, status)
+		})
+	}
+
+	return fmt.Errorf("Can not lock transactions with %T.", database)
+}
+
+func appendLockedTransactionStatus(tx *pg.Tx, status *db.TransactionStatus) error {
+	transaction := db.Transaction{ID: status.TransactionID}
+	if err := tx.Model(&transaction).Column("id").WherePK().For("UPDATE").Select(); err != nil {
+		return &core.WrappedError{
+			Message:       "Could not find transaction.",
+			InternalError: err,
+		}
+	}
+
+	current, err := currentTransactionStatus(tx, status.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	if err := validateTransactionStatusTransition(current, status.Status); err != nil {
+		return err
+	}
+
+	if status.CreatedAt.IsZero() {
+		status.CreatedAt = time.Now()
+	}
+
+	if err := tx.Insert(status); err != nil {
+		return &core.WrappedError{
+			Message:       "Could not create transaction status.",
+			InternalError: err,
+		}
+	}
+
+	return nil
+}
+
+// Appends a status for changes that already happened, such as a payment being captured. Failures
+// are logged.
+func recordTransactionStatus(database orm.DB, transactionID int, status string) {
+	if err := appendTransactionStatus(database, &db.TransactionStatus{
+		TransactionID: transactionID,
+		Status:        status,
+	}); err != nil {
+		fmt.Println("Failed to create " + status + " transaction status.")
+		fmt.Println(err)
+	}
+}
+
+var TransactionStatusEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "TransactionStatus",
+	Values: func() graphql.EnumValueConfigMap {
+		values := graphql.EnumValueConfigMap{}
+		for status, definition := range transactionStatuses {
+			values[status] = &graphql.EnumValueConfig{
+				Value:       status,
+				Description: definition.Description,
+			}
+		}
+
+		return values
+	}(),
+})
+
+var TransactionStatusType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TransactionStatusEvent",
+	Fields: graphql.Fields{
+		"status": &graphql.Field{
+			Type: graphql.NewNonNull(TransactionStatusEnum),
+		},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+		},
+		"carrier": &graphql.Field{
+			Type: graphql.String,
+		},
+		"trackingId": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+var TransactionStatusField = &graphql.Field{
+	Type:        TransactionStatusEnum,
+	Description: "The order status of the transaction. Payment statuses are only in the status history.",
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		transactionStatuses := params.Context.Value("transactionStatuses").(*dataloader.Loader)
+
+		transaction := params.Source.(*db.Transaction)
+
+		thunk := transactionStatuses.Load(params.Context, dataloaders.IntKey(transaction.ID))
+
+		return func() (interface{}, error) {
+			result, err := thunk()
+			if err != nil {
+				return nil, err
+			}
+
+			statuses, _ := result.([]*db.TransactionStatus)
+			for index := len(statuses) - 1; index >= 0; index-- {
+				if !transactionStatusIsPayment(statuses[index].Status) {
+					return statuses[index].Status, nil
+				}
+			}
+
+			return nil, nil
+		}, nil
+	},
+}
+
+var TransactionStatusHistoryField = &graphql.Field{
+	Type:        graphql.NewList(graphql.NewNonNull(TransactionStatusType)),
+	Description: "Every order and payment status of the transaction in the order they were recorded.",
+	Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+		transactionStatuses := params.Context.Value("transactionStatuses").(*dataloader.Loader)
+
+		transaction := params.Source.(*db.Transaction)
+
+		thunk := transactionStatuses.Load(params.Context, dataloaders.IntKey(transaction.ID))
+
+		return func() (interface{}, error) {
+			return thunk()
+		}, nil
+	},
+}
+
+func transactionStatusIsPayment(status string) bool {
+	return transactionStatuses[status].Payment
+}
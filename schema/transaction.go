@@ -1,16 +1,11 @@
 package schema
 
 import (
-	"math"
-	"strconv"
-
-	"github.com/jacob-ebey/go-shippo/client"
 	"github.com/graph-gophers/dataloader"
 	"github.com/graphql-go/graphql"
 	"github.com/jacob-ebey/golang-ecomm/auth"
 	"github.com/jacob-ebey/golang-ecomm/dataloaders"
 	"github.com/jacob-ebey/golang-ecomm/db"
-	core "github.com/jacob-ebey/graphql-core"
 )
 
 var TransactionType = graphql.NewObject(graphql.ObjectConfig{
@@ -19,6 +14,13 @@ var TransactionType = graphql.NewObject(graphql.ObjectConfig{
 		"id": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
+		"createdAt": &graphql.Field{
+			Type:        graphql.DateTime,
+			Description: "When the order was placed.",
+		},
+		"updatedAt": &graphql.Field{
+			Type: graphql.DateTime,
+		},
 		"subtotal": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
@@ -28,6 +30,24 @@ var TransactionType = graphql.NewObject(graphql.ObjectConfig{
 		"shipping": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
+		"shippingTax": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Int),
+		},
+		"shippingTaxDetails": &graphql.Field{
+			Type: graphql.NewList(graphql.NewNonNull(TaxDetailType)),
+		},
+		"paymentStatus": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The status of the payment with the payment gateway, such as authorized or submitted_for_settlement.",
+		},
+		"disputed": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Boolean),
+			Description: "If the customer has opened a dispute for the payment.",
+		},
+		"taxCommitted": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.Boolean),
+			Description: "If the taxes of the transaction have been committed to the tax provider.",
+		},
 		"total": &graphql.Field{
 			Type: graphql.NewNonNull(graphql.Int),
 		},
@@ -35,67 +55,51 @@ var TransactionType = graphql.NewObject(graphql.ObjectConfig{
 			Type: graphql.String,
 		},
 		"shippingLabel": &graphql.Field{
-			Type: ShippingLabelType,
+			Type:        ShippingLabelType,
+			Description: "The shipping label of a transaction with a single shipment.",
 			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
-				shippoClient := params.Context.Value("shippo").(*client.Client)
-
 				transaction := params.Source.(*db.Transaction)
 
 				if transaction.ShippoTransactionID == "" {
 					return nil, nil
 				}
 
-				label, err := shippoClient.RetrieveTransaction(transaction.ShippoTransactionID)
-				if err != nil {
-					return nil, &core.WrappedError{
-						Message:       "Could not retrieve shipping label.",
-						InternalError: err,
-					}
-				}
-
-				return map[string]interface{}{
-					"id":       label.ObjectID,
-					"labelUrl": label.LabelURL,
-				}, nil
+				return retrieveShippingLabel(params.Context, transaction.ShippoTransactionID)
 			},
 		},
 		"shippingEstimation": &graphql.Field{
-			Type: ShippingRateType,
+			Type:        ShippingRateType,
+			Description: "The shipping rate of a transaction with a single shipment.",
 			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
-				shippoClient := params.Context.Value("shippo").(*client.Client)
-
 				transaction := params.Source.(*db.Transaction)
 
 				if transaction.ShippoRateID == "" {
 					return nil, nil
 				}
 
-				rate, err := shippoClient.RetrieveRate(transaction.ShippoRateID)
-				if err != nil || rate == nil {
-					return nil, &core.WrappedError{
-						Message:       "Could not retrieve shipping rate.",
-						InternalError: err,
-					}
-				}
+				return retrieveShippingEstimation(params.Context, transaction.ShippoRateID)
+			},
+		},
+		"shipments": &graphql.Field{
+			Type:        graphql.NewList(graphql.NewNonNull(TransactionShipmentType)),
+			Description: "The packages of the transaction, one per origin.",
+			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+				transactionShipments := params.Context.Value("transactionShipments").(*dataloader.Loader)
 
-				amount, err := strconv.ParseFloat(rate.Amount, 64)
-				if err != nil {
-					return nil, &core.WrappedError{
-						Message:       "Could not convert estimation price.",
-						InternalError: err,
-					}
-				}
-				price := int(math.Round(amount * 100))
-
-				return &dataloaders.ShippingEstimation{
-					ID:            rate.ObjectID,
-					Price:         price,
-					Carrier:       rate.Provider,
-					Service:       rate.ServiceLevel.Name,
-					DurationTerms: rate.DurationTerms,
+				transaction := params.Source.(*db.Transaction)
+
+				thunk := transactionShipments.Load(params.Context, dataloaders.IntKey(transaction.ID))
+
+				return func() (interface{}, error) {
+					return thunk()
 				}, nil
 			},
 		},
+		"status":         TransactionStatusField,
+		"statusHistory":  TransactionStatusHistoryField,
+		"refunds":        TransactionRefundsField,
+		"invoiceUrl":     InvoiceURLField,
+		"packingSlipUrl": PackingSlipURLField,
 		"lineItems": &graphql.Field{
 			Type: graphql.NewList(graphql.NewNonNull(ReceiptLineItemType)),
 			Resolve: func(params graphql.ResolveParams) (interface{}, error) {
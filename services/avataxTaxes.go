@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jacob-ebey/golang-ecomm/apis"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	core "github.com/jacob-ebey/graphql-core"
+)
+
+// Looks up tax rates with Avatax.
+type AvataxTaxProvider struct {
+	Avatax *apis.Avatax
+}
+
+func (provider *AvataxTaxProvider) TaxRatesByAddress(ctx context.Context, address db.Address) (*TaxRates, error) {
+	rates, err := provider.Avatax.TaxRatesByAddress(apis.AvataxAddress{
+		Line1:      address.Line1,
+		Line2:      address.Line2,
+		Line3:      address.Line3,
+		City:       address.City,
+		Region:     address.Region,
+		Country:    address.Country,
+		PostalCode: address.PostalCode,
+	})
+	if err != nil {
+		switch err.(type) {
+		case *apis.AvataxError:
+			if strings.Contains(err.Error(), "CreateTransaction()") {
+				return nil, &core.WrappedError{
+					Message:       "Could not get taxes for address.",
+					InternalError: err,
+				}
+			}
+
+			return nil, err
+		default:
+			return nil, &core.WrappedError{
+				Message:       "Could not get taxes for address.",
+				InternalError: err,
+			}
+		}
+	}
+
+	subRates := make([]TaxRate, len(rates.Rates))
+	for index, rate := range rates.Rates {
+		subRates[index] = TaxRate{
+			Rate: rate.Rate,
+			Name: rate.Name,
+			Type: rate.Type,
+		}
+	}
+
+	return &TaxRates{
+		TotalRate: rates.TotalRate,
+		Rates:     subRates,
+	}, nil
+}
+
+func toCents(amount float64) int {
+	return int(math.Round(amount * 100))
+}
+
+func convertAvataxDetails(details []apis.AvataxTransactionDetail) []*db.TaxDetail {
+	converted := make([]*db.TaxDetail, len(details))
+	for index, detail := range details {
+		converted[index] = &db.TaxDetail{
+			Name: detail.TaxName,
+			Type: detail.JurisType,
+			Rate: detail.Rate,
+			Tax:  toCents(detail.Tax),
+		}
+	}
+
+	return converted
+}
+
+func (provider *AvataxTaxProvider) CalculateTax(ctx context.Context, request TaxRequest) (*TaxCalculation, error) {
+	transactionType := "SalesOrder"
+	if request.DocumentCode != "" {
+		transactionType = "SalesInvoice"
+	}
+
+	customerCode := request.CustomerCode
+	if customerCode == "" {
+		customerCode = "guest"
+	}
+
+	lines := []apis.AvataxTransactionLine{}
+	for index, line := range request.Lines {
+		lines = append(lines, apis.AvataxTransactionLine{
+			Number:      strconv.Itoa(index),
+			Quantity:    float64(line.Quantity),
+			Amount:      float64(line.Amount) / 100,
+			TaxCode:     line.TaxCode,
+			ItemCode:    strconv.Itoa(line.ID),
+			Description: line.Description,
+		})
+	}
+	if request.Shipping > 0 {
+		lines = append(lines, apis.AvataxTransactionLine{
+			Number:      "shipping",
+			Quantity:    1,
+			Amount:      float64(request.Shipping) / 100,
+			TaxCode:     ShippingTaxCode,
+			Description: "Shipping",
+		})
+	}
+
+	transaction, err := provider.Avatax.CreateTransaction(apis.AvataxCreateTransaction{
+		Type:         transactionType,
+		Code:         request.DocumentCode,
+		CompanyCode:  provider.Avatax.CompanyCode,
+		Date:         time.Now().Format("2006-01-02"),
+		CustomerCode: customerCode,
+		CurrencyCode: "USD",
+		Addresses: apis.AvataxTransactionAddresses{
+			SingleLocation: apis.AvataxTransactionAddress{
+				Line1:      request.Address.Line1,
+				Line2:      request.Address.Line2,
+				Line3:      request.Address.Line3,
+				City:       request.Address.City,
+				Region:     request.Address.Region,
+				PostalCode: request.Address.PostalCode,
+				Country:    request.Address.Country,
+			},
+		},
+		Lines: lines,
+	})
+	if err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not calculate taxes.",
+			InternalError: err,
+		}
+	}
+
+	calculation := &TaxCalculation{
+		Lines: make([]*TaxLineResult, len(request.Lines)),
+	}
+	for index, line := range request.Lines {
+		calculation.Lines[index] = &TaxLineResult{
+			ID:      line.ID,
+			Details: []*db.TaxDetail{},
+		}
+	}
+
+	for _, line := range transaction.Lines {
+		tax := toCents(line.Tax)
+		calculation.TotalTax += tax
+
+		if line.LineNumber == "shipping" {
+			calculation.ShippingTax = tax
+			calculation.ShippingDetails = convertAvataxDetails(line.Details)
+			continue
+		}
+
+		index, err := strconv.Atoi(line.LineNumber)
+		if err != nil || index < 0 || index >= len(calculation.Lines) {
+			return nil, &core.WrappedError{
+				Message: "Could not match calculated taxes to the order.",
+			}
+		}
+
+		calculation.Lines[index].Tax = tax
+		calculation.Lines[index].Details = convertAvataxDetails(line.Details)
+	}
+
+	return calculation, nil
+}
+
+func (provider *AvataxTaxProvider) CommitTax(ctx context.Context, documentCode string) error {
+	if _, err := provider.Avatax.CommitTransaction(provider.Avatax.CompanyCode, documentCode); err != nil {
+		return &core.WrappedError{
+			Message:       "Could not commit taxes.",
+			InternalError: err,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/braintree-go/braintree-go"
+	core "github.com/jacob-ebey/graphql-core"
+)
+
+// Charges payment methods through Braintree.
+type BraintreePaymentGateway struct {
+	Client *braintree.Braintree
+}
+
+func decimalToCents(decimal *braintree.Decimal) int {
+	if decimal == nil {
+		return 0
+	}
+
+	unscaled := decimal.Unscaled
+	for scale := decimal.Scale; scale < 2; scale++ {
+		unscaled *= 10
+	}
+	for scale := decimal.Scale; scale > 2; scale-- {
+		unscaled /= 10
+	}
+
+	return int(unscaled)
+}
+
+// Braintree treats a missing amount as the whole transaction.
+func optionalAmount(amount int) []*braintree.Decimal {
+	if amount <= 0 {
+		return nil
+	}
+
+	return []*braintree.Decimal{braintree.NewDecimal(int64(amount), 2)}
+}
+
+func convertBraintreeTransaction(transaction *braintree.Transaction) *PaymentTransaction {
+	return &PaymentTransaction{
+		ID:     transaction.Id,
+		Status: string(transaction.Status),
+		Amount: decimalToCents(transaction.Amount),
+	}
+}
+
+func (gateway *BraintreePaymentGateway) ClientToken(ctx context.Context) (string, error) {
+	token, err := gateway.Client.ClientToken().Generate(ctx)
+	if err != nil {
+		return "", &core.WrappedError{
+			Message:       "Could not create braintree client token.",
+			InternalError: err,
+		}
+	}
+
+	return token, nil
+}
+
+func (gateway *BraintreePaymentGateway) Retrieve(ctx context.Context, transactionID string) (*PaymentTransaction, error) {
+	transaction, err := gateway.Client.Transaction().Find(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertBraintreeTransaction(transaction), nil
+}
+
+func (gateway *BraintreePaymentGateway) FindByOrderID(ctx context.Context, orderID string) (*PaymentTransaction, error) {
+	query := new(braintree.SearchQuery)
+	query.AddTextField("order-id").Is = orderID
+
+	result, err := gateway.Client.Transaction().Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transaction := range result.Transactions {
+		switch transaction.Status {
+		case braintree.TransactionStatusProcessorDeclined, braintree.TransactionStatusGatewayRejected, braintree.TransactionStatusFailed:
+			continue
+		}
+
+		return convertBraintreeTransaction(transaction), nil
+	}
+
+	return nil, nil
+}
+
+func (gateway *BraintreePaymentGateway) Sale(ctx context.Context, request PaymentRequest) (*PaymentTransaction, error) {
+	lineItems := make([]*braintree.TransactionLineItemRequest, len(request.LineItems))
+	for index, lineItem := range request.LineItems {
+		lineItems[index] = &braintree.TransactionLineItemRequest{
+			Kind:        braintree.TransactionLineItemKindDebit,
+			Quantity:    braintree.NewDecimal(int64(lineItem.Quantity), 0),
+			Name:        lineItem.Name,
+			UnitAmount:  braintree.NewDecimal(int64(lineItem.UnitAmount), 2),
+			TotalAmount: braintree.NewDecimal(int64(lineItem.TotalAmount), 2),
+		}
+	}
+
+	shippingAddress := request.ShippingAddress
+
+	extendedAddress := shippingAddress.Line2 + "," + shippingAddress.Line3
+	if extendedAddress == "," {
+		extendedAddress = ""
+	}
+
+	address := braintree.Address{
+		StreetAddress:   shippingAddress.Line1,
+		ExtendedAddress: extendedAddress,
+		Locality:        shippingAddress.City,
+		Region:          shippingAddress.Region,
+		PostalCode:      shippingAddress.PostalCode,
+	}
+
+	if _, err := strconv.Atoi(shippingAddress.Country); err == nil {
+		address.CountryCodeNumeric = shippingAddress.Country
+	} else if len(shippingAddress.Country) == 2 {
+		address.CountryCodeAlpha2 = shippingAddress.Country
+	} else if len(shippingAddress.Country) == 3 {
+		address.CountryCodeAlpha3 = shippingAddress.Country
+	} else {
+		address.CountryName = shippingAddress.Country
+	}
+
+	transaction, err := gateway.Client.Transaction().Create(ctx, &braintree.TransactionRequest{
+		Type:               "sale",
+		PaymentMethodNonce: request.Nonce,
+		Options: &braintree.TransactionOptions{
+			SubmitForSettlement: request.SubmitForSettlement,
+		},
+		OrderId:         request.OrderID,
+		Amount:          braintree.NewDecimal(int64(request.Amount), 2),
+		TaxAmount:       braintree.NewDecimal(int64(request.TaxAmount), 2),
+		LineItems:       lineItems,
+		ShippingAddress: &address,
+	})
+	// Braintree answers declined and invalid sales with an unprocessable entity response.
+	if apiErr, ok := err.(*braintree.BraintreeError); ok && apiErr.StatusCode() == http.StatusUnprocessableEntity {
+		return nil, &SaleDeclinedError{Err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return convertBraintreeTransaction(transaction), nil
+}
+
+func (gateway *BraintreePaymentGateway) Void(ctx context.Context, transactionID string) (*PaymentTransaction, error) {
+	transaction, err := gateway.Client.Transaction().Void(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertBraintreeTransaction(transaction), nil
+}
+
+func (gateway *BraintreePaymentGateway) Refund(ctx context.Context, transactionID string, amount int) (*PaymentTransaction, error) {
+	transaction, err := gateway.Client.Transaction().Refund(ctx, transactionID, optionalAmount(amount)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertBraintreeTransaction(transaction), nil
+}
+
+func (gateway *BraintreePaymentGateway) Capture(ctx context.Context, transactionID string, amount int) (*PaymentTransaction, error) {
+	transaction, err := gateway.Client.Transaction().SubmitForSettlement(ctx, transactionID, optionalAmount(amount)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertBraintreeTransaction(transaction), nil
+}
+
+func (gateway *BraintreePaymentGateway) ParseWebhook(r *http.Request) (*PaymentEvent, error) {
+	notification, err := gateway.Client.WebhookNotification().ParseRequest(r)
+	if err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not parse braintree webhook.",
+			InternalError: err,
+		}
+	}
+
+	event := &PaymentEvent{
+		Timestamp: notification.Timestamp,
+	}
+
+	switch notification.Kind {
+	case braintree.CheckWebhook:
+		event.Kind = PaymentEventCheck
+	case braintree.TransactionSettledWebhook, braintree.TransactionSettlementDeclinedWebhook:
+		transaction := notification.Subject.Transaction
+		if transaction == nil {
+			return event, nil
+		}
+
+		event.TransactionID = transaction.Id
+		event.Amount = decimalToCents(transaction.Amount)
+
+		// Refunds are credits that settle against the sale they refund.
+		if transaction.Type == "credit" && transaction.RefundedTransactionId != nil {
+			if notification.Kind == braintree.TransactionSettledWebhook {
+				event.Kind = PaymentEventRefunded
+				event.TransactionID = *transaction.RefundedTransactionId
+				event.RefundID = transaction.Id
+			}
+		} else if notification.Kind == braintree.TransactionSettledWebhook {
+			event.Kind = PaymentEventSettled
+		} else {
+			event.Kind = PaymentEventSettlementDeclined
+		}
+	case braintree.DisputeOpenedWebhook, braintree.DisputeWonWebhook, braintree.DisputeLostWebhook:
+		dispute := notification.Dispute()
+		if dispute == nil || dispute.Transaction == nil {
+			return event, nil
+		}
+
+		event.TransactionID = dispute.Transaction.ID
+		event.Amount = decimalToCents(dispute.AmountDisputed)
+
+		switch notification.Kind {
+		case braintree.DisputeOpenedWebhook:
+			event.Kind = PaymentEventDisputeOpened
+		case braintree.DisputeWonWebhook:
+			event.Kind = PaymentEventDisputeWon
+		default:
+			event.Kind = PaymentEventDisputeLost
+		}
+	}
+
+	return event, nil
+}
+
+func (gateway *BraintreePaymentGateway) VerifyWebhook(challenge string) (string, error) {
+	return gateway.Client.WebhookNotification().Verify(challenge)
+}
+
+// Creates a signed webhook request of a kind for a transaction without calling Braintree.
+func (gateway *BraintreePaymentGateway) SampleWebhook(kind string, transactionID string) (*http.Request, error) {
+	return gateway.Client.WebhookTesting().Request(kind, transactionID)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/braintree-go/braintree-go"
+)
+
+func newTestBraintreeGateway() *BraintreePaymentGateway {
+	return &BraintreePaymentGateway{
+		Client: braintree.New(braintree.Sandbox, "merchant-id", "public-key", "private-key"),
+	}
+}
+
+// Reads the form of a sample webhook so it can be changed before it is parsed.
+func sampleWebhookForm(t *testing.T, gateway *BraintreePaymentGateway, kind string, transactionID string) url.Values {
+	r, err := gateway.SampleWebhook(kind, transactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return form
+}
+
+func TestBraintreeParseWebhookValid(t *testing.T) {
+	gateway := newTestBraintreeGateway()
+
+	r, err := gateway.SampleWebhook(braintree.TransactionSettledWebhook, "sale-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := gateway.ParseWebhook(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Kind != PaymentEventSettled {
+		t.Errorf("Expected a %s event, got %s.", PaymentEventSettled, event.Kind)
+	}
+	if event.TransactionID != "sale-1" {
+		t.Errorf("Expected the event for sale-1, got %s.", event.TransactionID)
+	}
+	if event.Amount != 10000 {
+		t.Errorf("Expected an amount of 10000, got %d.", event.Amount)
+	}
+	if event.Timestamp.IsZero() {
+		t.Error("Expected the event to have a timestamp.")
+	}
+}
+
+func TestBraintreeParseWebhookTampered(t *testing.T) {
+	gateway := newTestBraintreeGateway()
+
+	form := sampleWebhookForm(t, gateway, braintree.TransactionSettledWebhook, "sale-1")
+	declined := sampleWebhookForm(t, gateway, braintree.TransactionSettlementDeclinedWebhook, "sale-1")
+	form.Set("bt_payload", declined.Get("bt_payload"))
+
+	if _, err := gateway.ParseWebhook(newWebhookRequest(form)); err == nil {
+		t.Error("Expected a webhook with a changed payload to be rejected.")
+	}
+}
+
+func TestBraintreeParseWebhookOtherKey(t *testing.T) {
+	gateway := newTestBraintreeGateway()
+	forger := &BraintreePaymentGateway{
+		Client: braintree.New(braintree.Sandbox, "merchant-id", "public-key", "guessed-key"),
+	}
+
+	r, err := forger.SampleWebhook(braintree.TransactionSettledWebhook, "sale-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gateway.ParseWebhook(r); err == nil {
+		t.Error("Expected a webhook signed with another key to be rejected.")
+	}
+}
+
+func TestBraintreeParseWebhookUnsigned(t *testing.T) {
+	gateway := newTestBraintreeGateway()
+
+	form := sampleWebhookForm(t, gateway, braintree.TransactionSettledWebhook, "sale-1")
+	form.Del("bt_signature")
+
+	r, _ := http.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if _, err := gateway.ParseWebhook(r); err == nil {
+		t.Error("Expected a webhook without a signature to be rejected.")
+	}
+}
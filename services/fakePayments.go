@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// Nonces the fake payment gateway understands, named after Braintree's sandbox nonces.
+const (
+	FakeValidNonce             = "fake-valid-nonce"
+	FakeProcessorDeclinedNonce = "fake-processor-declined-nonce"
+)
+
+var PaymentDeclinedError = fmt.Errorf("The payment was declined.")
+var PaymentTransactionNotFoundError = fmt.Errorf("Could not find payment transaction.")
+var InvalidPaymentTransactionStatusError = fmt.Errorf("The payment transaction can not be changed from its current status.")
+var InvalidPaymentAmountError = fmt.Errorf("The amount is more than the payment transaction allows.")
+var WebhookSignatureError = fmt.Errorf("The webhook signature is not valid.")
+var WebhookSecretMissingError = fmt.Errorf("The fake payment gateway has no webhook secret to sign webhooks with.")
+
+// An in-process payment gateway for development. Transactions are kept in memory and numbered in
+// the order they are created. Sales with FakeProcessorDeclinedNonce are declined, any other nonce
+// is charged. Webhooks are signed with WebhookSecret, and rejected when it is empty.
+type FakePaymentGateway struct {
+	WebhookSecret string
+
+	mutex        sync.Mutex
+	nextID       int
+	transactions map[string]*fakePaymentTransaction
+}
+
+type fakePaymentTransaction struct {
+	PaymentTransaction
+	orderID  string
+	refunded int
+}
+
+func (gateway *FakePaymentGateway) create(status string, amount int) *fakePaymentTransaction {
+	if gateway.transactions == nil {
+		gateway.transactions = map[string]*fakePaymentTransaction{}
+	}
+
+	gateway.nextID++
+	transaction := &fakePaymentTransaction{
+		PaymentTransaction: PaymentTransaction{
+			ID:     "fake-" + strconv.Itoa(gateway.nextID),
+			Status: status,
+			Amount: amount,
+		},
+	}
+	gateway.transactions[transaction.ID] = transaction
+
+	return transaction
+}
+
+func (gateway *FakePaymentGateway) find(transactionID string) (*fakePaymentTransaction, error) {
+	transaction, ok := gateway.transactions[transactionID]
+	if !ok {
+		return nil, PaymentTransactionNotFoundError
+	}
+
+	return transaction, nil
+}
+
+func (gateway *FakePaymentGateway) ClientToken(ctx context.Context) (string, error) {
+	return "fake-client-token", nil
+}
+
+func (gateway *FakePaymentGateway) Retrieve(ctx context.Context, transactionID string) (*PaymentTransaction, error) {
+	gateway.mutex.Lock()
+	defer gateway.mutex.Unlock()
+
+	transaction, err := gateway.find(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := transaction.PaymentTransaction
+
+	return &result, nil
+}
+
+func (gateway *FakePaymentGateway) FindByOrderID(ctx context.Context, orderID string) (*PaymentTransaction, error) {
+	gateway.mutex.Lock()
+	defer gateway.mutex.Unlock()
+
+	for _, transaction := range gateway.transactions {
+		if transaction.orderID == orderID {
+			result := transaction.PaymentTransaction
+
+			return &result, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (gateway *FakePaymentGateway) Sale(ctx context.Context, request PaymentRequest) (*PaymentTransaction, error) {
+	gateway.mutex.Lock()
+	defer gateway.mutex.Unlock()
+
+	if request.Nonce == FakeProcessorDeclinedNonce {
+		return nil, &SaleDeclinedError{Err: PaymentDeclinedError}
+	}
+
+	status := PaymentStatusAuthorized
+	if request.SubmitForSettlement {
+		status = PaymentStatusSubmittedForSettlement
+	}
+
+	transaction := gateway.create(status, request.Amount)
+	transaction.orderID = request.OrderID
+	result := transaction.PaymentTransaction
+
+	return &result, nil
+}
+
+func (gateway *FakePaymentGateway) Void(ctx context.Context, transactionID string) (*PaymentTransaction, error) {
+	gateway.mutex.Lock()
+	defer gateway.mutex.Unlock()
+
+	transaction, err := gateway.find(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.Status != PaymentStatusAuthorized && transaction.Status != PaymentStatusSubmittedForSettlement {
+		return nil, InvalidPaymentTransactionStatusError
+	}
+
+	transaction.Status = PaymentStatusVoided
+	result := transaction.PaymentTransaction
+
+	return &result, nil
+}
+
+// Transactions settle as soon as they are captured, so they can be refunded right away.
+func (gateway *FakePaymentGateway) Refund(ctx context.Context, transactionID string, amount int) (*PaymentTransaction, error) {
+	gateway.mutex.Lock()
+	defer gateway.mutex.Unlock()
+
+	transaction, err := gateway.find(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.Status != PaymentStatusSubmittedForSettlement && transaction.Status != PaymentStatusSettled {
+		return nil, InvalidPaymentTransactionStatusError
+	}
+
+	if amount <= 0 {
+		amount = transaction.Amount - transaction.refunded
+	}
+	if amount <= 0 || transaction.refunded+amount > transaction.Amount {
+		return nil, InvalidPaymentAmountError
+	}
+
+	transaction.Status = PaymentStatusSettled
+	transaction.refunded += amount
+
+	refund := gateway.create(PaymentStatusSubmittedForSettlement, amount)
+	result := refund.PaymentTransaction
+
+	return &result, nil
+}
+
+func (gateway *FakePaymentGateway) Capture(ctx context.Context, transactionID string, amount int) (*PaymentTransaction, error) {
+	gateway.mutex.Lock()
+	defer gateway.mutex.Unlock()
+
+	transaction, err := gateway.find(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.Status != PaymentStatusAuthorized {
+		return nil, InvalidPaymentTransactionStatusError
+	}
+
+	if amount > transaction.Amount {
+		return nil, InvalidPaymentAmountError
+	}
+	if amount > 0 {
+		transaction.Amount = amount
+	}
+
+	transaction.Status = PaymentStatusSubmittedForSettlement
+	result := transaction.PaymentTransaction
+
+	return &result, nil
+}
+
+func (gateway *FakePaymentGateway) sign(payload string) (string, error) {
+	if gateway.WebhookSecret == "" {
+		return "", WebhookSecretMissingError
+	}
+
+	mac := hmac.New(sha256.New, []byte(gateway.WebhookSecret))
+	mac.Write([]byte(payload))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Creates the form of a signed webhook request for an event, the same shape Braintree posts.
+func (gateway *FakePaymentGateway) SignWebhook(event PaymentEvent) (url.Values, error) {
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := base64.StdEncoding.EncodeToString(encoded)
+
+	signature, err := gateway.sign(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Add("bt_signature", signature)
+	form.Add("bt_payload", payload)
+
+	return form, nil
+}
+
+func (gateway *FakePaymentGateway) ParseWebhook(r *http.Request) (*PaymentEvent, error) {
+	signature := r.PostFormValue("bt_signature")
+	payload := r.PostFormValue("bt_payload")
+
+	expected, err := gateway.sign(payload)
+	if err != nil {
+		return nil, err
+	}
+	if signature == "" || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, WebhookSignatureError
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &PaymentEvent{}
+	if err := json.Unmarshal(decoded, event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (gateway *FakePaymentGateway) VerifyWebhook(challenge string) (string, error) {
+	signature, err := gateway.sign(challenge)
+	if err != nil {
+		return "", err
+	}
+
+	return "fake|" + signature, nil
+}
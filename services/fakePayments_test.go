@@ -0,0 +1,105 @@
+package services
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newWebhookRequest(form url.Values) *http.Request {
+	r, _ := http.NewRequest(http.MethodPost, "/webhooks/payments", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return r
+}
+
+func TestFakeParseWebhookValid(t *testing.T) {
+	gateway := &FakePaymentGateway{WebhookSecret: "secret"}
+
+	sent := PaymentEvent{
+		Kind:          PaymentEventRefunded,
+		Timestamp:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		TransactionID: "fake-1",
+		RefundID:      "fake-2",
+		Amount:        500,
+	}
+	form, err := gateway.SignWebhook(sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := gateway.ParseWebhook(newWebhookRequest(form))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Kind != sent.Kind ||
+		!event.Timestamp.Equal(sent.Timestamp) ||
+		event.TransactionID != sent.TransactionID ||
+		event.RefundID != sent.RefundID ||
+		event.Amount != sent.Amount {
+		t.Errorf("Parsed %+v, expected %+v.", event, sent)
+	}
+}
+
+func TestFakeParseWebhookTampered(t *testing.T) {
+	gateway := &FakePaymentGateway{WebhookSecret: "secret"}
+
+	form, err := gateway.SignWebhook(PaymentEvent{Kind: PaymentEventSettled, TransactionID: "fake-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := gateway.SignWebhook(PaymentEvent{Kind: PaymentEventRefunded, TransactionID: "fake-1", Amount: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	form.Set("bt_payload", forged.Get("bt_payload"))
+
+	if _, err := gateway.ParseWebhook(newWebhookRequest(form)); err != WebhookSignatureError {
+		t.Errorf("Expected WebhookSignatureError, got %v.", err)
+	}
+}
+
+func TestFakeParseWebhookOtherSecret(t *testing.T) {
+	gateway := &FakePaymentGateway{WebhookSecret: "secret"}
+	forger := &FakePaymentGateway{WebhookSecret: "guessed"}
+
+	form, err := forger.SignWebhook(PaymentEvent{Kind: PaymentEventSettled, TransactionID: "fake-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gateway.ParseWebhook(newWebhookRequest(form)); err != WebhookSignatureError {
+		t.Errorf("Expected WebhookSignatureError, got %v.", err)
+	}
+}
+
+func TestFakeParseWebhookUnsigned(t *testing.T) {
+	gateway := &FakePaymentGateway{WebhookSecret: "secret"}
+
+	form, err := gateway.SignWebhook(PaymentEvent{Kind: PaymentEventSettled, TransactionID: "fake-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	form.Del("bt_signature")
+
+	if _, err := gateway.ParseWebhook(newWebhookRequest(form)); err != WebhookSignatureError {
+		t.Errorf("Expected WebhookSignatureError, got %v.", err)
+	}
+}
+
+func TestFakeParseWebhookWithoutSecret(t *testing.T) {
+	gateway := &FakePaymentGateway{}
+
+	if _, err := gateway.SignWebhook(PaymentEvent{Kind: PaymentEventSettled}); err != WebhookSecretMissingError {
+		t.Errorf("Expected WebhookSecretMissingError when signing, got %v.", err)
+	}
+
+	form := url.Values{}
+	form.Add("bt_signature", "")
+	form.Add("bt_payload", "e30=")
+	if _, err := gateway.ParseWebhook(newWebhookRequest(form)); err != WebhookSecretMissingError {
+		t.Errorf("Expected WebhookSecretMissingError when parsing, got %v.", err)
+	}
+}
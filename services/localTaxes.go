@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	core "github.com/jacob-ebey/graphql-core"
+)
+
+// Looks up tax rates from the tax rules in the database. Every rule that matches the address
+// applies, so state, county and city rates add up to the total rate. Each rule is applied to the
+// line amounts and rounded to the cent on its own.
+type LocalTaxProvider struct{}
+
+func findTaxRules(database *pg.DB, address db.Address) ([]*db.TaxRule, error) {
+	rules := []*db.TaxRule{}
+	if err := database.
+		Model(&rules).
+		Where("upper(tax_rule.country) = upper(?)", strings.TrimSpace(address.Country)).
+		OrderExpr("tax_rule.id ASC").
+		Select(); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not get taxes for address.",
+			InternalError: err,
+		}
+	}
+
+	postalCode := normalizePostalCode(address.PostalCode)
+
+	matches := []*db.TaxRule{}
+	for _, rule := range rules {
+		if rule.Region != "" && !strings.EqualFold(rule.Region, strings.TrimSpace(address.Region)) {
+			continue
+		}
+
+		if !strings.HasPrefix(postalCode, normalizePostalCode(rule.PostalCodePrefix)) {
+			continue
+		}
+
+		matches = append(matches, rule)
+	}
+
+	return matches, nil
+}
+
+func (provider *LocalTaxProvider) TaxRatesByAddress(ctx context.Context, address db.Address) (*TaxRates, error) {
+	database := ctx.Value("database").(*pg.DB)
+
+	rules, err := findTaxRules(database, address)
+	if err != nil {
+		return nil, err
+	}
+
+	taxes := &TaxRates{
+		Rates: []TaxRate{},
+	}
+	for _, rule := range rules {
+		taxes.TotalRate += rule.Rate
+		taxes.Rates = append(taxes.Rates, TaxRate{
+			Rate: rule.Rate,
+			Name: rule.Name,
+			Type: rule.Type,
+		})
+	}
+
+	return taxes, nil
+}
+
+func applyTaxRule(rule *db.TaxRule, amount int) *db.TaxDetail {
+	return &db.TaxDetail{
+		Name: rule.Name,
+		Type: rule.Type,
+		Rate: rule.Rate,
+		Tax:  int(math.Round(float64(amount) * rule.Rate)),
+	}
+}
+
+func exempts(rule *db.TaxRule, taxCode string) bool {
+	for _, exempt := range rule.ExemptTaxCodes {
+		if taxCode != "" && strings.EqualFold(exempt, taxCode) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (provider *LocalTaxProvider) CalculateTax(ctx context.Context, request TaxRequest) (*TaxCalculation, error) {
+	database := ctx.Value("database").(*pg.DB)
+
+	rules, err := findTaxRules(database, request.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	calculation := &TaxCalculation{
+		Lines:           make([]*TaxLineResult, len(request.Lines)),
+		ShippingDetails: []*db.TaxDetail{},
+	}
+
+	for index, line := range request.Lines {
+		result := &TaxLineResult{
+			ID:      line.ID,
+			Details: []*db.TaxDetail{},
+		}
+
+		for _, rule := range rules {
+			if exempts(rule, line.TaxCode) {
+				continue
+			}
+
+			detail := applyTaxRule(rule, line.Amount)
+			result.Tax += detail.Tax
+			result.Details = append(result.Details, detail)
+		}
+
+		calculation.TotalTax += result.Tax
+		calculation.Lines[index] = result
+	}
+
+	for _, rule := range rules {
+		if !rule.TaxesShipping {
+			continue
+		}
+
+		detail := applyTaxRule(rule, request.Shipping)
+		calculation.ShippingTax += detail.Tax
+		calculation.ShippingDetails = append(calculation.ShippingDetails, detail)
+	}
+	calculation.TotalTax += calculation.ShippingTax
+
+	return calculation, nil
+}
+
+// Local calculations are not reported anywhere, so there is nothing to commit.
+func (provider *LocalTaxProvider) CommitTax(ctx context.Context, documentCode string) error {
+	return nil
+}
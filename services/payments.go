@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+// A line of a sale. Amounts are in cents.
+type PaymentLineItem struct {
+	Name        string
+	Quantity    int
+	UnitAmount  int
+	TotalAmount int
+}
+
+// A sale to charge to a payment method nonce. Amounts are in cents.
+type PaymentRequest struct {
+	OrderID         string
+	Nonce           string
+	Amount          int
+	TaxAmount       int
+	LineItems       []PaymentLineItem
+	ShippingAddress db.Address
+	// Captures the payment right away instead of only authorizing it.
+	SubmitForSettlement bool
+}
+
+// Statuses of gateway transactions, as Braintree names them.
+const (
+	PaymentStatusAuthorized             = "authorized"
+	PaymentStatusAuthorizationExpired   = "authorization_expired"
+	PaymentStatusSubmittedForSettlement = "submitted_for_settlement"
+	PaymentStatusSettled                = "settled"
+	PaymentStatusVoided                 = "voided"
+)
+
+// A transaction with the payment gateway. Amounts are in cents.
+type PaymentTransaction struct {
+	ID     string
+	Status string
+	Amount int
+}
+
+// The error of a sale the gateway decided not to charge, such as a declined card or a request that
+// failed validation. Other sale errors, such as timeouts, leave it unknown whether the sale went
+// through.
+type SaleDeclinedError struct {
+	Err error
+}
+
+func (e *SaleDeclinedError) Error() string {
+	return e.Err.Error()
+}
+
+func IsSaleDeclined(err error) bool {
+	_, ok := err.(*SaleDeclinedError)
+	return ok
+}
+
+// Kinds of events payment gateways notify us of with webhooks.
+const (
+	PaymentEventCheck              = "CHECK"
+	PaymentEventSettled            = "SETTLED"
+	PaymentEventSettlementDeclined = "SETTLEMENT_DECLINED"
+	PaymentEventRefunded           = "REFUNDED"
+	PaymentEventDisputeOpened      = "DISPUTE_OPENED"
+	PaymentEventDisputeWon         = "DISPUTE_WON"
+	PaymentEventDisputeLost        = "DISPUTE_LOST"
+)
+
+// An event from a payment gateway webhook. Events the store does not handle have an empty kind.
+type PaymentEvent struct {
+	Kind      string
+	Timestamp time.Time
+	// The ID of the sale the event is for.
+	TransactionID string
+	// The ID of the refund of refund events.
+	RefundID string
+	Amount   int
+}
+
+// Charges payment methods. Transactions are referenced by the gateway's IDs.
+type PaymentGateway interface {
+	ClientToken(ctx context.Context) (string, error)
+	Retrieve(ctx context.Context, transactionID string) (*PaymentTransaction, error)
+	// Finds the sale made for an order, ignoring declined and rejected attempts. Returns nil when the
+	// order was never charged.
+	FindByOrderID(ctx context.Context, orderID string) (*PaymentTransaction, error)
+	// Charges a payment method. Returns a *SaleDeclinedError when the gateway declined the sale.
+	Sale(ctx context.Context, request PaymentRequest) (*PaymentTransaction, error)
+	Void(ctx context.Context, transactionID string) (*PaymentTransaction, error)
+	// Refunds the amount of a settled transaction. An amount of 0 refunds the whole transaction.
+	Refund(ctx context.Context, transactionID string, amount int) (*PaymentTransaction, error)
+	// Captures the amount of an authorized transaction. An amount of 0 captures the whole transaction.
+	Capture(ctx context.Context, transactionID string, amount int) (*PaymentTransaction, error)
+	// Verifies the signature of a webhook request and parses its event.
+	ParseWebhook(r *http.Request) (*PaymentEvent, error)
+	// Answers the challenge the gateway sends to verify a webhook URL.
+	VerifyWebhook(challenge string) (string, error)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+	core "github.com/jacob-ebey/graphql-core"
+)
+
+// A parcel to rate. Dimensions are in inches and weights in ounces.
+type ShippingParcel struct {
+	Length float64
+	Width  float64
+	Height float64
+	Weight float64
+}
+
+// A rate to ship parcels with a carrier's service. Prices are in cents.
+type ShippingEstimation struct {
+	ID            string
+	Price         int
+	Service       string
+	ServiceToken  string
+	Carrier       string
+	DurationTerms string
+	// When the rate can no longer be selected at checkout. Zero when the provider does not say.
+	ExpiresAt time.Time
+	// The origin, destination and parcels the rate was quoted for, when the provider stores them.
+	// Empty when the provider does not.
+	OriginID    int
+	Destination string
+	ParcelHash  string
+}
+
+// Formats the parts of an address that shipping is rated by, so rates quoted for an address can be
+// matched with it later.
+func shippingDestination(address db.Address) string {
+	parts := []string{
+		address.Line1,
+		address.Line2,
+		address.Line3,
+		address.City,
+		address.Region,
+		normalizePostalCode(address.PostalCode),
+		address.Country,
+	}
+	for index, part := range parts {
+		parts[index] = strings.ToUpper(strings.TrimSpace(part))
+	}
+
+	return strings.Join(parts, "\n")
+}
+
+func hashShippingParcels(parcels []ShippingParcel) string {
+	hash := sha256.New()
+	for _, parcel := range parcels {
+		fmt.Fprintf(hash, "%.2f|%.2f|%.2f|%.2f\n", parcel.Length, parcel.Width, parcel.Height, parcel.Weight)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Whether the rate was quoted for shipping the parcels from the origin to the address. Rates of
+// providers that do not store what they were quoted for always match.
+func (estimation *ShippingEstimation) RatedFor(from db.Address, to db.Address, parcels []ShippingParcel) bool {
+	if estimation.ParcelHash == "" {
+		return true
+	}
+
+	return estimation.OriginID == from.ID &&
+		estimation.Destination == shippingDestination(to) &&
+		estimation.ParcelHash == hashShippingParcels(parcels)
+}
+
+type ShippingLabel struct {
+	ID             string
+	LabelURL       string
+	Carrier        string
+	TrackingNumber string
+	TrackingURL    string
+}
+
+// Statuses of parcels in transit that shipping providers notify us of with webhooks.
+const (
+	TrackingStatusInTransit      = "IN_TRANSIT"
+	TrackingStatusOutForDelivery = "OUT_FOR_DELIVERY"
+	TrackingStatusDelivered      = "DELIVERED"
+	TrackingStatusReturned       = "RETURNED"
+)
+
+var TrackingWebhooksNotSupportedError = fmt.Errorf("The shipping provider does not send tracking webhooks.")
+var TrackingWebhookTokenError = fmt.Errorf("The tracking webhook token is not valid.")
+
+// A tracking update from a shipping provider webhook. Updates the store does not track have an
+// empty status.
+type TrackingEvent struct {
+	Status         string
+	Timestamp      time.Time
+	Carrier        string
+	TrackingNumber string
+	LabelID        string
+}
+
+// Rates parcels and purchases their labels. Rates and labels are referenced by the provider's IDs.
+type ShippingProvider interface {
+	ValidateAddress(ctx context.Context, address db.Address) (bool, error)
+	RateParcels(ctx context.Context, from db.Address, to db.Address, parcels []ShippingParcel) ([]*ShippingEstimation, error)
+	RetrieveRate(ctx context.Context, rateID string) (*ShippingEstimation, error)
+	PurchaseLabel(ctx context.Context, rateID string) (*ShippingLabel, error)
+	RetrieveLabel(ctx context.Context, labelID string) (*ShippingLabel, error)
+	// Verifies a tracking webhook request and parses its update.
+	ParseTrackingWebhook(r *http.Request) (*TrackingEvent, error)
+}
+
+var ValidateAddressWithShippingProvider validateAddressFunc = func(ctx context.Context, address db.Address) (bool, error) {
+	shippingProvider := ctx.Value("shippingProvider").(ShippingProvider)
+
+	valid, err := shippingProvider.ValidateAddress(ctx, address)
+	if err != nil || !valid {
+		return false, &core.WrappedError{
+			Message:       "Address is not valid.",
+			InternalError: err,
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jacob-ebey/go-shippo/client"
+	"github.com/jacob-ebey/go-shippo/models"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	core "github.com/jacob-ebey/graphql-core"
+)
+
+// Rates and purchases labels through Shippo.
+type ShippoShippingProvider struct {
+	Client *client.Client
+	// Shippo does not sign webhooks, so the webhook URL carries this token as its token parameter.
+	WebhookToken string
+}
+
+type shippoTrackWebhook struct {
+	Event string `json:"event"`
+	Data  struct {
+		Carrier        string `json:"carrier"`
+		TrackingNumber string `json:"tracking_number"`
+		Transaction    string `json:"transaction"`
+		TrackingStatus *struct {
+			Status     string    `json:"status"`
+			StatusDate time.Time `json:"status_date"`
+			Substatus  *struct {
+				Code string `json:"code"`
+			} `json:"substatus"`
+		} `json:"tracking_status"`
+	} `json:"data"`
+}
+
+func createAddress(shippoClient *client.Client, address db.Address) (*models.Address, error) {
+	return shippoClient.CreateAddress(&models.AddressInput{
+		Name:     address.Name,
+		Street1:  address.Line1,
+		Street2:  address.Line2,
+		Street3:  address.Line3,
+		City:     address.City,
+		State:    address.Region,
+		Zip:      address.PostalCode,
+		Country:  address.Country,
+		Validate: true,
+	})
+}
+
+func convertShippoRate(rate *models.Rate) (*ShippingEstimation, error) {
+	amount, err := strconv.ParseFloat(rate.Amount, 64)
+	if err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not convert estimation price.",
+			InternalError: err,
+		}
+	}
+
+	estimation := &ShippingEstimation{
+		ID:            rate.ObjectID,
+		Price:         int(math.Round(amount * 100)),
+		Carrier:       rate.Provider,
+		DurationTerms: rate.DurationTerms,
+	}
+
+	if rate.ServiceLevel != nil {
+		estimation.Service = rate.ServiceLevel.Name
+		estimation.ServiceToken = rate.ServiceLevel.Token
+	}
+
+	return estimation, nil
+}
+
+func (provider *ShippoShippingProvider) ValidateAddress(ctx context.Context, address db.Address) (bool, error) {
+	addr, err := createAddress(provider.Client, address)
+	if err != nil || addr == nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (provider *ShippoShippingProvider) RateParcels(
+	ctx context.Context,
+	fromAddr db.Address,
+	toAddr db.Address,
+	parcels []ShippingParcel) ([]*ShippingEstimation, error) {
+	addressFrom, err := createAddress(provider.Client, fromAddr)
+	if err != nil {
+		return nil, err // TODO: Lookover the type of messages this error has
+	}
+
+	addressTo, err := createAddress(provider.Client, toAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	parcelIDs := make([]string, len(parcels))
+	for index, parcel := range parcels {
+		created, err := provider.Client.CreateParcel(&models.ParcelInput{
+			Length:       fmt.Sprintf("%.2f", parcel.Length),
+			Width:        fmt.Sprintf("%.2f", parcel.Width),
+			Height:       fmt.Sprintf("%.2f", parcel.Height),
+			DistanceUnit: models.DistanceUnitInch,
+			Weight:       fmt.Sprintf("%.2f", parcel.Weight),
+			MassUnit:     models.MassUnitOunce,
+		})
+		if err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not create parcel.",
+				InternalError: err,
+			}
+		}
+
+		parcelIDs[index] = created.ObjectID
+	}
+
+	shipment, err := provider.Client.CreateShipment(&models.ShipmentInput{
+		AddressFrom: addressFrom.ObjectID,
+		AddressTo:   addressTo.ObjectID,
+		Parcels:     parcelIDs,
+		Async:       false,
+	})
+	if err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not create shipping estimation.",
+			InternalError: err,
+		}
+	}
+
+	estimations := make([]*ShippingEstimation, len(shipment.Rates))
+	for index, rate := range shipment.Rates {
+		estimations[index], err = convertShippoRate(rate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return estimations, nil
+}
+
+func (provider *ShippoShippingProvider) RetrieveRate(ctx context.Context, rateID string) (*ShippingEstimation, error) {
+	rate, err := provider.Client.RetrieveRate(rateID)
+	if err != nil || rate == nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not retrieve shipping rate.",
+			InternalError: err,
+		}
+	}
+
+	return convertShippoRate(rate)
+}
+
+func (provider *ShippoShippingProvider) PurchaseLabel(ctx context.Context, rateID string) (*ShippingLabel, error) {
+	rate, err := provider.Client.RetrieveRate(rateID)
+	if err != nil || rate == nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not retrieve shipping rate.",
+			InternalError: err,
+		}
+	}
+
+	label, err := provider.Client.PurchaseShippingLabel(&models.TransactionInput{
+		Rate:          rate.ObjectID,
+		LabelFileType: models.LabelFileTypePDF,
+		Async:         false,
+	})
+	if err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not purchase shipping label.",
+			InternalError: err,
+		}
+	}
+
+	if label.Status == "ERROR" {
+		message := "Could not purchase shipping label."
+		if len(label.Messages) > 0 {
+			message = label.Messages[0].Text
+		}
+
+		return nil, &core.WrappedError{
+			Message: message,
+		}
+	}
+
+	return &ShippingLabel{
+		ID:             label.ObjectID,
+		LabelURL:       label.LabelURL,
+		Carrier:        rate.Provider,
+		TrackingNumber: label.TrackingNumber,
+		TrackingURL:    label.TrackingURLProvider,
+	}, nil
+}
+
+func (provider *ShippoShippingProvider) RetrieveLabel(ctx context.Context, labelID string) (*ShippingLabel, error) {
+	label, err := provider.Client.RetrieveTransaction(labelID)
+	if err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not retrieve shipping label.",
+			InternalError: err,
+		}
+	}
+
+	return &ShippingLabel{
+		ID:             label.ObjectID,
+		LabelURL:       label.LabelURL,
+		TrackingNumber: label.TrackingNumber,
+		TrackingURL:    label.TrackingURLProvider,
+	}, nil
+}
+
+func (provider *ShippoShippingProvider) ParseTrackingWebhook(r *http.Request) (*TrackingEvent, error) {
+	token := r.URL.Query().Get("token")
+	if provider.WebhookToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(provider.WebhookToken)) != 1 {
+		return nil, TrackingWebhookTokenError
+	}
+
+	webhook := shippoTrackWebhook{}
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not parse shippo webhook.",
+			InternalError: err,
+		}
+	}
+
+	event := &TrackingEvent{
+		Timestamp:      time.Now(),
+		Carrier:        webhook.Data.Carrier,
+		TrackingNumber: webhook.Data.TrackingNumber,
+		LabelID:        webhook.Data.Transaction,
+	}
+
+	trackingStatus := webhook.Data.TrackingStatus
+	if webhook.Event != "track_updated" || trackingStatus == nil {
+		return event, nil
+	}
+
+	if !trackingStatus.StatusDate.IsZero() {
+		event.Timestamp = trackingStatus.StatusDate
+	}
+
+	switch trackingStatus.Status {
+	case "TRANSIT":
+		event.Status = TrackingStatusInTransit
+		if trackingStatus.Substatus != nil && trackingStatus.Substatus.Code == "out_for_delivery" {
+			event.Status = TrackingStatusOutForDelivery
+		}
+	case "DELIVERED":
+		event.Status = TrackingStatusDelivered
+	case "RETURNED":
+		event.Status = TrackingStatusReturned
+	}
+
+	return event, nil
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg/v9"
+	"github.com/jacob-ebey/golang-ecomm/db"
+	core "github.com/jacob-ebey/graphql-core"
+)
+
+const tableLabelPrefix = "table-label-"
+
+// Rate quotes can be selected at checkout for this long. Quotes that no order shipped with are
+// deleted once they have been expired for as long again.
+const shippingRateQuoteLifetime = 24 * time.Hour
+
+var NoShippingZoneError = fmt.Errorf("We do not ship to the provided address.")
+
+// Rates parcels from the weight and zone tables in the database without calling any carrier. Rates
+// only depend on the destination, but each quote is stored with the origin, destination and parcels
+// it was rated for so it cannot be used for another shipment. Labels are not real carrier labels, so they have no label URL or
+// tracking number.
+type TableRateShippingProvider struct{}
+
+func newQuoteID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+func normalizePostalCode(postalCode string) string {
+	return strings.ToUpper(strings.Replace(postalCode, " ", "", -1))
+}
+
+// Finds the most specific zone for the address. Postal code prefixes are more specific than regions.
+func findShippingZone(database *pg.DB, address db.Address) (*db.ShippingZone, error) {
+	zones := []*db.ShippingZone{}
+	if err := database.
+		Model(&zones).
+		Where("upper(shipping_zone.country) = upper(?)", strings.TrimSpace(address.Country)).
+		Relation("Rates").
+		OrderExpr("shipping_zone.id ASC").
+		Select(); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not get shipping zones.",
+			InternalError: err,
+		}
+	}
+
+	postalCode := normalizePostalCode(address.PostalCode)
+
+	var match *db.ShippingZone
+	matchScore := -1
+	for _, zone := range zones {
+		if zone.Region != "" && !strings.EqualFold(zone.Region, strings.TrimSpace(address.Region)) {
+			continue
+		}
+
+		prefix := normalizePostalCode(zone.PostalCodePrefix)
+		if !strings.HasPrefix(postalCode, prefix) {
+			continue
+		}
+
+		score := len(prefix) * 2
+		if zone.Region != "" {
+			score++
+		}
+
+		if score > matchScore {
+			match = zone
+			matchScore = score
+		}
+	}
+
+	if match == nil {
+		return nil, NoShippingZoneError
+	}
+
+	return match, nil
+}
+
+func (provider *TableRateShippingProvider) ValidateAddress(ctx context.Context, address db.Address) (bool, error) {
+	if strings.TrimSpace(address.Line1) == "" ||
+		strings.TrimSpace(address.City) == "" ||
+		strings.TrimSpace(address.Region) == "" ||
+		strings.TrimSpace(address.PostalCode) == "" ||
+		strings.TrimSpace(address.Country) == "" {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (provider *TableRateShippingProvider) RateParcels(
+	ctx context.Context,
+	fromAddr db.Address,
+	toAddr db.Address,
+	parcels []ShippingParcel) ([]*ShippingEstimation, error) {
+	database := ctx.Value("database").(*pg.DB)
+
+	// Zones are defined by destination only, so every origin rates the same.
+	zone, err := findShippingZone(database, toAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceRates := map[string][]*db.ShippingZoneRate{}
+	serviceKeys := []string{}
+	for _, rate := range zone.Rates {
+		key := rate.Carrier + "|" + rate.Service
+		if _, ok := serviceRates[key]; !ok {
+			serviceKeys = append(serviceKeys, key)
+		}
+
+		serviceRates[key] = append(serviceRates[key], rate)
+	}
+
+	now := time.Now()
+	destination := shippingDestination(toAddr)
+	parcelHash := hashShippingParcels(parcels)
+	quotes := []*db.ShippingRateQuote{}
+	for _, key := range serviceKeys {
+		rates := serviceRates[key]
+		sort.SliceStable(rates, func(i, j int) bool {
+			return rates[i].MaxWeight < rates[j].MaxWeight
+		})
+
+		price := 0
+		available := true
+		for _, parcel := range parcels {
+			var match *db.ShippingZoneRate
+			for _, rate := range rates {
+				if rate.MaxWeight >= parcel.Weight {
+					match = rate
+					break
+				}
+			}
+
+			if match == nil {
+				available = false
+				break
+			}
+
+			price += match.Price
+		}
+
+		if !available {
+			continue
+		}
+
+		id, err := newQuoteID()
+		if err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not create shipping estimation.",
+				InternalError: err,
+			}
+		}
+
+		quotes = append(quotes, &db.ShippingRateQuote{
+			ID:            id,
+			CreatedAt:     now,
+			ExpiresAt:     now.Add(shippingRateQuoteLifetime),
+			OriginID:      fromAddr.ID,
+			Destination:   destination,
+			ParcelHash:    parcelHash,
+			Carrier:       rates[0].Carrier,
+			Service:       rates[0].Service,
+			DurationTerms: rates[0].DurationTerms,
+			Price:         price,
+		})
+	}
+
+	sort.SliceStable(quotes, func(i, j int) bool {
+		return quotes[i].Price < quotes[j].Price
+	})
+
+	if len(quotes) > 0 {
+		if err := database.Insert(&quotes); err != nil {
+			return nil, &core.WrappedError{
+				Message:       "Could not create shipping estimation.",
+				InternalError: err,
+			}
+		}
+	}
+
+	estimations := make([]*ShippingEstimation, len(quotes))
+	for index, quote := range quotes {
+		estimations[index] = convertShippingRateQuote(quote)
+	}
+
+	return estimations, nil
+}
+
+func convertShippingRateQuote(quote *db.ShippingRateQuote) *ShippingEstimation {
+	return &ShippingEstimation{
+		ID:            quote.ID,
+		Price:         quote.Price,
+		Carrier:       quote.Carrier,
+		Service:       quote.Service,
+		ServiceToken:  quote.Service,
+		DurationTerms: quote.DurationTerms,
+		ExpiresAt:     quote.ExpiresAt,
+		OriginID:      quote.OriginID,
+		Destination:   quote.Destination,
+		ParcelHash:    quote.ParcelHash,
+	}
+}
+
+// Deletes the rate quotes that expired a lifetime ago and that no shipment was rated with. Every
+// shipping estimation stores a quote, so this keeps the table from growing with every visit to the
+// cart. Returns the number of quotes deleted.
+func DeleteExpiredShippingRateQuotes(ctx context.Context) (int, error) {
+	database := ctx.Value("database").(*pg.DB)
+
+	result, err := database.
+		Model((*db.ShippingRateQuote)(nil)).
+		Where("shipping_rate_quote.expires_at < ?", time.Now().Add(-shippingRateQuoteLifetime)).
+		Where("NOT EXISTS (SELECT 1 FROM transaction_shipments WHERE transaction_shipments.shippo_rate_id = shipping_rate_quote.id)").
+		Delete()
+	if err != nil {
+		return 0, &core.WrappedError{
+			Message:       "Could not delete expired shipping rate quotes.",
+			InternalError: err,
+		}
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func retrieveShippingRateQuote(ctx context.Context, rateID string) (*db.ShippingRateQuote, error) {
+	database := ctx.Value("database").(*pg.DB)
+
+	quote := db.ShippingRateQuote{ID: rateID}
+	if err := database.Select(&quote); err != nil {
+		return nil, &core.WrappedError{
+			Message:       "Could not retrieve shipping rate.",
+			InternalError: err,
+		}
+	}
+
+	return &quote, nil
+}
+
+func (provider *TableRateShippingProvider) RetrieveRate(ctx context.Context, rateID string) (*ShippingEstimation, error) {
+	quote, err := retrieveShippingRateQuote(ctx, rateID)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertShippingRateQuote(quote), nil
+}
+
+func (provider *TableRateShippingProvider) PurchaseLabel(ctx context.Context, rateID string) (*ShippingLabel, error) {
+	quote, err := retrieveShippingRateQuote(ctx, rateID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShippingLabel{
+		ID:      tableLabelPrefix + quote.ID,
+		Carrier: quote.Carrier,
+	}, nil
+}
+
+func (provider *TableRateShippingProvider) RetrieveLabel(ctx context.Context, labelID string) (*ShippingLabel, error) {
+	if !strings.HasPrefix(labelID, tableLabelPrefix) {
+		return nil, &core.WrappedError{
+			Message: "Could not retrieve shipping label.",
+		}
+	}
+
+	quote, err := retrieveShippingRateQuote(ctx, strings.TrimPrefix(labelID, tableLabelPrefix))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ShippingLabel{
+		ID:      labelID,
+		Carrier: quote.Carrier,
+	}, nil
+}
+
+// Table rate labels are bought outside of the store, so there is nothing to track.
+func (provider *TableRateShippingProvider) ParseTrackingWebhook(r *http.Request) (*TrackingEvent, error) {
+	return nil, TrackingWebhooksNotSupportedError
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+
+	"github.com/jacob-ebey/golang-ecomm/db"
+)
+
+type TaxRate struct {
+	Rate float64
+	Name string
+	Type string
+}
+
+type TaxRates struct {
+	TotalRate float64
+	Rates     []TaxRate
+}
+
+// The tax code for shipping by common carrier.
+const ShippingTaxCode = "FR020100"
+
+// A line to calculate tax for. Amounts are in cents.
+type TaxLine struct {
+	ID          int
+	Quantity    int
+	Amount      int
+	TaxCode     string
+	Description string
+}
+
+// When DocumentCode is set the provider saves the calculation under that code so it can be committed
+// once the order is paid.
+type TaxRequest struct {
+	DocumentCode string
+	CustomerCode string
+	Address      db.Address
+	Lines        []TaxLine
+	Shipping     int
+}
+
+type TaxLineResult struct {
+	ID      int
+	Tax     int
+	Details []*db.TaxDetail
+}
+
+type TaxCalculation struct {
+	TotalTax        int
+	Lines           []*TaxLineResult
+	ShippingTax     int
+	ShippingDetails []*db.TaxDetail
+}
+
+type TaxProvider interface {
+	// Looks up the tax rates that apply to an address.
+	TaxRatesByAddress(ctx context.Context, address db.Address) (*TaxRates, error)
+	// Calculates the tax for each line and for shipping.
+	CalculateTax(ctx context.Context, request TaxRequest) (*TaxCalculation, error)
+	// Commits a saved calculation so it is reported in the store's filings.
+	CommitTax(ctx context.Context, documentCode string) error
+}
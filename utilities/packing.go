@@ -0,0 +1,170 @@
+package utilities
+
+import (
+	"sort"
+)
+
+// A box size parcels can be packed into. Dimensions are in inches and weights in ounces.
+type PackingBox struct {
+	ID        int
+	Length    float64
+	Width     float64
+	Height    float64
+	Weight    float64
+	MaxWeight float64
+}
+
+// A single unit to pack. Dimensions are in inches and weights in ounces.
+type PackingItem struct {
+	ID     int
+	Length float64
+	Width  float64
+	Height float64
+	Weight float64
+}
+
+// A parcel of packed items. Box is nil when the item did not fit any box and ships in its own packaging.
+type PackedParcel struct {
+	Box    *PackingBox
+	Length float64
+	Width  float64
+	Height float64
+	Weight float64
+	Items  []PackingItem
+}
+
+func volume(length, width, height float64) float64 {
+	return length * width * height
+}
+
+func sortedDimensions(length, width, height float64) []float64 {
+	dimensions := []float64{length, width, height}
+	sort.Float64s(dimensions)
+	return dimensions
+}
+
+// Checks if the item fits in the box in any orientation.
+func (box *PackingBox) fitsDimensions(item PackingItem) bool {
+	boxDimensions := sortedDimensions(box.Length, box.Width, box.Height)
+	itemDimensions := sortedDimensions(item.Length, item.Width, item.Height)
+
+	for i := range boxDimensions {
+		if itemDimensions[i] > boxDimensions[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Checks if the items fit in the box together. Items are measured by volume, so this assumes they
+// can be arranged to fill the box.
+func (box *PackingBox) fits(items []PackingItem) bool {
+	remainingVolume := volume(box.Length, box.Width, box.Height)
+	remainingWeight := box.MaxWeight - box.Weight
+
+	for _, item := range items {
+		if !box.fitsDimensions(item) {
+			return false
+		}
+
+		remainingVolume -= volume(item.Length, item.Width, item.Height)
+		remainingWeight -= item.Weight
+	}
+
+	return remainingVolume >= 0 && remainingWeight >= 0
+}
+
+func (parcel *PackedParcel) setBox(box *PackingBox) {
+	parcel.Box = box
+	parcel.Length = box.Length
+	parcel.Width = box.Width
+	parcel.Height = box.Height
+	parcel.Weight = box.Weight
+
+	for _, item := range parcel.Items {
+		parcel.Weight += item.Weight
+	}
+}
+
+// Packs the items into as few boxes as it can using first fit decreasing. Each parcel is then moved
+// to the smallest box that still holds its items. Items that fit no box get a parcel of their own
+// with the item's dimensions. The result only depends on the input, so packing the same items
+// again gives the same parcels.
+func Pack(items []PackingItem, boxes []PackingBox) []*PackedParcel {
+	sortedItems := make([]PackingItem, len(items))
+	copy(sortedItems, items)
+	sort.SliceStable(sortedItems, func(i, j int) bool {
+		return volume(sortedItems[i].Length, sortedItems[i].Width, sortedItems[i].Height) >
+			volume(sortedItems[j].Length, sortedItems[j].Width, sortedItems[j].Height)
+	})
+
+	sortedBoxes := make([]*PackingBox, len(boxes))
+	for index := range boxes {
+		sortedBoxes[index] = &boxes[index]
+	}
+	sort.SliceStable(sortedBoxes, func(i, j int) bool {
+		return volume(sortedBoxes[i].Length, sortedBoxes[i].Width, sortedBoxes[i].Height) <
+			volume(sortedBoxes[j].Length, sortedBoxes[j].Width, sortedBoxes[j].Height)
+	})
+
+	parcels := []*PackedParcel{}
+	for _, item := range sortedItems {
+		packed := false
+		for _, parcel := range parcels {
+			if parcel.Box == nil {
+				continue
+			}
+
+			if parcel.Box.fits(append([]PackingItem{item}, parcel.Items...)) {
+				parcel.Items = append(parcel.Items, item)
+				packed = true
+				break
+			}
+		}
+
+		if packed {
+			continue
+		}
+
+		// Open the largest box the item fits in so later items can share it.
+		var box *PackingBox
+		for i := len(sortedBoxes) - 1; i >= 0; i-- {
+			if sortedBoxes[i].fits([]PackingItem{item}) {
+				box = sortedBoxes[i]
+				break
+			}
+		}
+
+		if box == nil {
+			parcels = append(parcels, &PackedParcel{
+				Length: item.Length,
+				Width:  item.Width,
+				Height: item.Height,
+				Weight: item.Weight,
+				Items:  []PackingItem{item},
+			})
+			continue
+		}
+
+		parcels = append(parcels, &PackedParcel{
+			Box:   box,
+			Items: []PackingItem{item},
+		})
+	}
+
+	for _, parcel := range parcels {
+		if parcel.Box == nil {
+			continue
+		}
+
+		for _, box := range sortedBoxes {
+			if box.fits(parcel.Items) {
+				parcel.setBox(box)
+				break
+			}
+		}
+	}
+
+	return parcels
+}
@@ -9,9 +9,14 @@ import (
 
 	"github.com/jacob-ebey/golang-ecomm/auth"
 	"github.com/jacob-ebey/golang-ecomm/runtime"
+	"github.com/jacob-ebey/golang-ecomm/schema"
 )
 
 var handler *httphandler.GraphQLHttpHandler
+var paymentWebhookHandler http.HandlerFunc
+var trackingWebhookHandler http.HandlerFunc
+var transactionExportHandler http.HandlerFunc
+var documentHandler http.HandlerFunc
 var err error
 
 func initialize() bool {
@@ -34,6 +39,10 @@ func initialize() bool {
 			Executor:   *executor,
 			Playground: true,
 		}
+		paymentWebhookHandler = runtime.NewContextHandler(executor, schema.HandlePaymentWebhook)
+		trackingWebhookHandler = runtime.NewContextHandler(executor, schema.HandleTrackingWebhook)
+		transactionExportHandler = runtime.NewContextHandler(executor, schema.HandleTransactionExport)
+		documentHandler = runtime.NewContextHandler(executor, schema.HandleDocument)
 	}
 
 	return true
@@ -47,5 +56,16 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	handler.ServeHTTP(w, r)
+	switch r.URL.Path {
+	case "/webhooks/payments":
+		paymentWebhookHandler(w, r)
+	case "/webhooks/shipping":
+		trackingWebhookHandler(w, r)
+	case "/exports/transactions":
+		transactionExportHandler(w, r)
+	case "/documents/invoice", "/documents/packing-slip":
+		documentHandler(w, r)
+	default:
+		handler.ServeHTTP(w, r)
+	}
 }